@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"blogpost/config"
+)
+
+// ErrMiss is returned by Store.Get when the key is absent or expired
+var ErrMiss = errors.New("cache: miss")
+
+// Store keeps cached responses and the version counters used to invalidate them
+type Store interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Version returns the current version of a namespace, 0 if it was never bumped
+	Version(ctx context.Context, namespace string) (int64, error)
+	// Bump moves a namespace to a new version, orphaning every key built on the old one
+	Bump(ctx context.Context, namespace string) error
+	Close() error
+}
+
+// NewStore builds the store selected by CACHE_STORE, nil means caching is off
+func NewStore(cfg config.Config) (Store, error) {
+	switch cfg.CacheStore {
+	case "", "none":
+		return nil, nil
+	case "memory":
+		return NewMemoryStore(cfg.CacheMaxEntries), nil
+	case "redis":
+		return NewRedisStore(cfg.RedisAddr, cfg.RedisPassword, cfg.RedisDB)
+	}
+	return nil, fmt.Errorf("unknown cache store %q", cfg.CacheStore)
+}
+
+// Entry is a cached response
+type Entry struct {
+	Status   int               `json:"status"`
+	Header   map[string]string `json:"header"`
+	Body     []byte            `json:"body"`
+	StoredAt time.Time         `json:"stored_at"`
+}
+
+// Age is how long ago the entry was stored
+func (e Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.StoredAt)
+}
+
+// Encode ...
+func (e Entry) Encode() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// DecodeEntry ...
+func DecodeEntry(b []byte) (Entry, error) {
+	var e Entry
+	err := json.Unmarshal(b, &e)
+	return e, err
+}
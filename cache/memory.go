@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryItem struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// MemoryStore is a process local Store, expired items are dropped lazily and
+// swept once the store grows past its limit
+type MemoryStore struct {
+	mu         sync.Mutex
+	items      map[string]memoryItem
+	versions   map[string]int64
+	maxEntries int
+}
+
+// NewMemoryStore ...
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		items:      make(map[string]memoryItem),
+		versions:   make(map[string]int64),
+		maxEntries: maxEntries,
+	}
+}
+
+// Get ...
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	item, ok := s.items[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	if time.Now().After(item.expiresAt) {
+		delete(s.items, key)
+		return nil, ErrMiss
+	}
+	return item.value, nil
+}
+
+// Set ...
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.maxEntries > 0 && len(s.items) >= s.maxEntries {
+		s.sweep()
+	}
+	if s.maxEntries > 0 && len(s.items) >= s.maxEntries {
+		// still full of live items, make room by dropping an arbitrary one
+		for k := range s.items {
+			delete(s.items, k)
+			break
+		}
+	}
+
+	s.items[key] = memoryItem{value: value, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+// Version ...
+func (s *MemoryStore) Version(ctx context.Context, namespace string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versions[namespace], nil
+}
+
+// Bump ...
+func (s *MemoryStore) Bump(ctx context.Context, namespace string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[namespace]++
+	return nil
+}
+
+// Close ...
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// sweep drops expired items, the caller holds the lock
+func (s *MemoryStore) sweep() {
+	now := time.Now()
+	for k, item := range s.items {
+		if now.After(item.expiresAt) {
+			delete(s.items, k)
+		}
+	}
+}
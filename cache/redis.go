@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix keeps gateway keys apart from anything else living in the same database
+const keyPrefix = "blogpost:cache:"
+
+// RedisStore is a Store on top of Redis or any server speaking its protocol
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore ...
+func NewRedisStore(addr, password string, db int) (*RedisStore, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: password,
+		DB:       db,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	return &RedisStore{client: client}, nil
+}
+
+// Get ...
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set ...
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, keyPrefix+key, value, ttl).Err()
+}
+
+// Version ...
+func (s *RedisStore) Version(ctx context.Context, namespace string) (int64, error) {
+	version, err := s.client.Get(ctx, keyPrefix+"version:"+namespace).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	return version, err
+}
+
+// Bump ...
+func (s *RedisStore) Bump(ctx context.Context, namespace string) error {
+	return s.client.Incr(ctx, keyPrefix+"version:"+namespace).Err()
+}
+
+// Close ...
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -1,6 +1,9 @@
 package clients
 
 import (
+	"context"
+	"time"
+
 	"blogpost/config"
 	"blogpost/genprotos/article"
 	"blogpost/genprotos/author"
@@ -17,19 +20,24 @@ type GrpcClients struct {
 }
 
 func NewGrpcClients(cfg config.Config) (*GrpcClients, error) {
-	connAuthor, err := grpc.Dial(cfg.AuthorServiceGrpcHost+cfg.AuthorServiceGrpcPort, grpc.WithInsecure())
+	opts := []grpc.DialOption{
+		grpc.WithInsecure(),
+		grpc.WithChainUnaryInterceptor(unaryInterceptors(cfg)...),
+	}
+
+	connAuthor, err := grpc.Dial(cfg.AuthorServiceGrpcHost+cfg.AuthorServiceGrpcPort, opts...)
 	if err != nil {
 		return nil, err
 	}
 	author := author.NewAuthorServicesClient(connAuthor)
 
-	connArticle, err := grpc.Dial(cfg.ArticleServiceGrpcHost+cfg.ArticleServiceGrpcPort, grpc.WithInsecure())
+	connArticle, err := grpc.Dial(cfg.ArticleServiceGrpcHost+cfg.ArticleServiceGrpcPort, opts...)
 	if err != nil {
 		return nil, err
 	}
 	article := article.NewArticleServicesClient(connArticle)
 
-	connAuthorization, err := grpc.Dial(cfg.AuthorizationServiceGrpcHost+cfg.AuthorizationServiceGrpcPort, grpc.WithInsecure())
+	connAuthorization, err := grpc.Dial(cfg.AuthorizationServiceGrpcHost+cfg.AuthorizationServiceGrpcPort, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -43,6 +51,31 @@ func NewGrpcClients(cfg config.Config) (*GrpcClients, error) {
 	}, nil
 }
 
+// unaryInterceptors wraps every call, the timeout comes first so coalesced calls share its deadline
+func unaryInterceptors(cfg config.Config) []grpc.UnaryClientInterceptor {
+	interceptors := make([]grpc.UnaryClientInterceptor, 0, 2)
+	if cfg.GrpcTimeout > 0 {
+		interceptors = append(interceptors, timeoutInterceptor(cfg.GrpcTimeout))
+	}
+	if cfg.GrpcCoalesceReads {
+		co := &coalescer{}
+		interceptors = append(interceptors, co.unaryInterceptor)
+	}
+	return interceptors
+}
+
+// timeoutInterceptor bounds calls that have no deadline of their own
+func timeoutInterceptor(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
 func (c *GrpcClients) Close() {
 	for _, v := range c.conns {
 		v.Close()
@@ -0,0 +1,87 @@
+package clients
+
+import (
+	"context"
+	"time"
+
+	"blogpost/metrics"
+
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// coalescedMethods are the read calls safe to share between identical concurrent requests
+var coalescedMethods = map[string]bool{
+	"/ArticleServices/GetArticleByID":       true,
+	"/ArticleServices/GetArticleList":       true,
+	"/AuthorServices/GetAuthorByID":         true,
+	"/AuthorServices/GetArticlesByAuthorID": true,
+	"/AuthorServices/GetAuthorList":         true,
+}
+
+type noCoalescingKey struct{}
+
+// WithoutCoalescing marks ctx so its calls always reach the backend on their own
+func WithoutCoalescing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCoalescingKey{}, true)
+}
+
+// coalescer lets only one call per method and request message be in flight, the others wait for its reply
+type coalescer struct {
+	group singleflight.Group
+}
+
+func (co *coalescer) unaryInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	reqMsg, okReq := req.(proto.Message)
+	replyMsg, okReply := reply.(proto.Message)
+	if !coalescedMethods[method] || !okReq || !okReply || ctx.Value(noCoalescingKey{}) != nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	reqBytes, err := proto.MarshalOptions{Deterministic: true}.Marshal(reqMsg)
+	if err != nil {
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	key := method + "|" + string(reqBytes)
+
+	ch := co.group.DoChan(key, func() (interface{}, error) {
+		// the shared call must not fail because the first caller went away, but it keeps its deadline
+		var callCtx context.Context = detachedContext{parent: ctx}
+		if deadline, ok := ctx.Deadline(); ok {
+			var cancel context.CancelFunc
+			callCtx, cancel = context.WithDeadline(callCtx, deadline)
+			defer cancel()
+		}
+
+		out := replyMsg.ProtoReflect().New().Interface()
+		err := invoker(callCtx, method, req, out, cc, opts...)
+		return out, err
+	})
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case res := <-ch:
+		if res.Shared {
+			metrics.CoalescedCalls.Add(method, 1)
+		}
+		if res.Err != nil {
+			return res.Err
+		}
+		proto.Merge(replyMsg, res.Val.(proto.Message))
+		return nil
+	}
+}
+
+// detachedContext keeps the values of its parent, metadata included, but not its cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
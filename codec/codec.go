@@ -0,0 +1,122 @@
+package codec
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Media types the gateway reads and writes besides JSON documents of its own
+const (
+	JSON     = "application/json"
+	XML      = "application/xml"
+	MsgPack  = "application/msgpack"
+	Protobuf = "application/x-protobuf"
+)
+
+// aliases are media types clients send for one of the supported ones
+var aliases = map[string]string{
+	"text/xml":                XML,
+	"application/x-msgpack":   MsgPack,
+	"application/protobuf":    Protobuf,
+	"application/vnd.msgpack": MsgPack,
+}
+
+// Canonical maps a Content-Type to the supported media type it names, parameters are dropped
+func Canonical(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+type acceptRange struct {
+	mediaType string
+	q         float64
+	index     int
+}
+
+// Negotiate ranks offered by an Accept header, the most preferred type first. Types the header
+// rules out are left out, an empty header accepts everything in the order offered
+func Negotiate(accept string, offered ...string) []string {
+	if strings.TrimSpace(accept) == "" {
+		return offered
+	}
+
+	var ranges []acceptRange
+	for i, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if alias, ok := aliases[mediaType]; ok {
+			mediaType = alias
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(raw, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q, index: i})
+	}
+
+	type candidate struct {
+		mediaType   string
+		q           float64
+		specificity int
+		index       int
+		offered     int
+	}
+	var candidates []candidate
+	for i, mediaType := range offered {
+		best := candidate{mediaType: mediaType, specificity: -1, offered: i}
+		for _, r := range ranges {
+			specificity := matches(r.mediaType, mediaType)
+			// the most specific range decides the quality of a type
+			if specificity > best.specificity {
+				best.q, best.specificity, best.index = r.q, specificity, r.index
+			}
+		}
+		if best.specificity >= 0 && best.q > 0 {
+			candidates = append(candidates, best)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.q != b.q {
+			return a.q > b.q
+		}
+		if a.specificity != b.specificity {
+			return a.specificity > b.specificity
+		}
+		if a.index != b.index {
+			return a.index < b.index
+		}
+		return a.offered < b.offered
+	})
+	ranked := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, c.mediaType)
+	}
+	return ranked
+}
+
+// matches returns how specifically pattern names mediaType, -1 when it does not
+func matches(pattern, mediaType string) int {
+	switch {
+	case pattern == mediaType:
+		return 2
+	case pattern == "*/*":
+		return 0
+	case strings.HasSuffix(pattern, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(pattern, "*")):
+		return 1
+	}
+	return -1
+}
@@ -0,0 +1,55 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// JSONToMsgPack writes a JSON document as MessagePack, integers stay integers and map keys are sorted
+func JSONToMsgPack(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetSortMapKeys(true)
+	if err := encoder.Encode(msgpackValue(value)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func msgpackValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i
+		}
+		f, _ := value.Float64()
+		return f
+	case map[string]interface{}:
+		for key, child := range value {
+			value[key] = msgpackValue(child)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = msgpackValue(child)
+		}
+	}
+	return v
+}
+
+// MsgPackToJSON reads a MessagePack document as JSON
+func MsgPackToJSON(data []byte) ([]byte, error) {
+	var value interface{}
+	if err := msgpack.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return json.Marshal(value)
+}
@@ -0,0 +1,276 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// xmlItem names the elements of an array, xmlEntry the members of an object whose key is not an XML name
+const (
+	xmlItem  = "item"
+	xmlEntry = "entry"
+)
+
+// JSONToXML writes a JSON document as XML under root. Object members become elements named by their key,
+// array items become item elements and null values are left out
+func JSONToXML(data []byte, root string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	if err := writeXML(encoder, decoder, root); err != nil {
+		return nil, err
+	}
+	if err := encoder.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeXML(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xmlStart(name)
+	switch value := token.(type) {
+	case json.Delim:
+		if err := encoder.EncodeToken(start); err != nil {
+			return err
+		}
+		for decoder.More() {
+			child := xmlItem
+			if value == '{' {
+				key, err := decoder.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+			}
+			if err := writeXML(encoder, decoder, child); err != nil {
+				return err
+			}
+		}
+		// the closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return err
+		}
+		return encoder.EncodeToken(start.End())
+	case nil:
+		return nil
+	case string:
+		return encoder.EncodeElement(value, start)
+	case json.Number:
+		return encoder.EncodeElement(value.String(), start)
+	case bool:
+		return encoder.EncodeElement(strconv.FormatBool(value), start)
+	}
+	return errors.New("codec: unexpected JSON token")
+}
+
+func xmlStart(name string) xml.StartElement {
+	if isXMLName(name) {
+		return xml.StartElement{Name: xml.Name{Local: name}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: xmlEntry},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+	}
+}
+
+func isXMLName(name string) bool {
+	if name == "" || strings.HasPrefix(strings.ToLower(name), "xml") {
+		return false
+	}
+	for i, r := range name {
+		if unicode.IsLetter(r) || r == '_' {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.') {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// xmlNode is an element of a parsed XML document
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+var (
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	timeType       = reflect.TypeOf(time.Time{})
+)
+
+// XMLToJSON reads XML shaped like the output of JSONToXML as the JSON document target would be decoded from,
+// target decides where arrays, numbers and booleans are as XML has only text
+func XMLToJSON(data []byte, target reflect.Type) ([]byte, error) {
+	root, err := parseXML(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(xmlValue(root, target))
+}
+
+func parseXML(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		root  *xmlNode
+		stack []*xmlNode
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			for _, attr := range t.Attr {
+				if t.Name.Local == xmlEntry && attr.Name.Local == "key" {
+					node.name = attr.Value
+				}
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("codec: empty XML document")
+	}
+	return root, nil
+}
+
+func xmlValue(node *xmlNode, t reflect.Type) interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == rawMessageType || t.Kind() == reflect.Interface {
+		return xmlGeneric(node)
+	}
+	if t == timeType {
+		return node.text
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]reflect.Type)
+		jsonFields(t, fields)
+		object := make(map[string]interface{}, len(node.children))
+		for _, child := range node.children {
+			// unknown elements are ignored like unknown JSON members
+			if ft, ok := fields[child.name]; ok {
+				object[child.name] = xmlValue(child, ft)
+			}
+		}
+		return object
+	case reflect.Map:
+		object := make(map[string]interface{}, len(node.children))
+		for _, child := range node.children {
+			object[child.name] = xmlValue(child, t.Elem())
+		}
+		return object
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return strings.TrimSpace(node.text)
+		}
+		list := make([]interface{}, 0, len(node.children))
+		for _, child := range node.children {
+			list = append(list, xmlValue(child, t.Elem()))
+		}
+		return list
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(strings.TrimSpace(node.text)); err == nil {
+			return b
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(strings.TrimSpace(node.text), 64); err == nil {
+			return json.Number(strings.TrimSpace(node.text))
+		}
+	}
+	// anything that does not parse is left as text for the JSON decoder to report
+	return node.text
+}
+
+// xmlGeneric reads an element without a target type, all item children make an array
+func xmlGeneric(node *xmlNode) interface{} {
+	if len(node.children) == 0 {
+		return node.text
+	}
+
+	array := true
+	for _, child := range node.children {
+		array = array && child.name == xmlItem
+	}
+	if array {
+		list := make([]interface{}, 0, len(node.children))
+		for _, child := range node.children {
+			list = append(list, xmlGeneric(child))
+		}
+		return list
+	}
+
+	object := make(map[string]interface{}, len(node.children))
+	for _, child := range node.children {
+		object[child.name] = xmlGeneric(child)
+	}
+	return object
+}
+
+// jsonFields adds the JSON member names of struct t with their types, embedded structs are flattened
+func jsonFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				jsonFields(embedded, fields)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
@@ -18,6 +19,48 @@ type Config struct {
 
 	DefaultOffset string
 	DefaultLimit  string
+	MaxLimit      int
+	ListScanMax   int // articles the gateway reads at most to sort or filter a list itself
+	ExpandWorkers int // concurrent lookups of one ?expand= request
+
+	ArticleListQueries bool // the article service sorts and filters GetArticleListReq, lists are paged there
+
+	CursorSecret string        // signs list cursors, every gateway instance needs the same one
+	CursorTTL    time.Duration // how long a list cursor can be used, 0 keeps them valid
+
+	PublicURL string // scheme and host links in feeds start with, feeds are off when empty
+	FeedTitle string
+	FeedSize  int // newest articles in a feed
+	FeedTTL   time.Duration
+
+	ExcerptLength  int // characters of a rendered article's excerpt
+	WordsPerMinute int // reading speed behind a rendered article's reading time
+
+	CompressEncodings     string // comma separated, the gateway's preference first
+	CompressMinSize       int    // bytes a response needs before it is compressed
+	CompressExcludedTypes string // comma separated Content-Type prefixes that are sent as they are
+
+	EventsReplaySize int // latest events kept for clients resuming with Last-Event-ID
+	EventsHeartbeat  time.Duration
+
+	LegacyRoutesDeprecatedAt string // YYYY-MM-DD
+	LegacyRoutesSunset       string // YYYY-MM-DD
+
+	CacheStore          string // memory, redis or none
+	CacheMaxEntries     int    // memory store only
+	CacheArticleTTL     time.Duration
+	CacheArticleListTTL time.Duration
+	CacheAuthorTTL      time.Duration
+	CacheAuthorListTTL  time.Duration
+
+	// a cached response older than its TTL is served while it is refreshed in the background for
+	// CacheStaleWhileRevalidate, and served instead of a failed backend call for CacheStaleIfError
+	CacheStaleWhileRevalidate time.Duration
+	CacheStaleIfError         time.Duration
+
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
 
 	AuthorServiceGrpcHost string
 	AuthorServiceGrpcPort string
@@ -27,6 +70,19 @@ type Config struct {
 
 	AuthorizationServiceGrpcHost string
 	AuthorizationServiceGrpcPort string
+
+	ImportMaxBytes   int64
+	ImportMaxRecords int
+	ImportWorkers    int
+
+	BatchMaxOperations int
+	BatchConcurrency   int
+
+	GraphQLMaxDepth      int
+	GraphQLMaxComplexity int // fields a query may resolve, the selection of a list counts once per item
+
+	GrpcCoalesceReads bool
+	GrpcTimeout       time.Duration
 }
 
 // Load ...
@@ -44,6 +100,46 @@ func Load() Config {
 
 	config.DefaultOffset = cast.ToString(getOrReturnDefaultValue("DEFAULT_OFFSET", "0"))
 	config.DefaultLimit = cast.ToString(getOrReturnDefaultValue("DEFAULT_LIMIT", "10"))
+	config.MaxLimit = cast.ToInt(getOrReturnDefaultValue("MAX_LIMIT", "100"))
+	config.ListScanMax = cast.ToInt(getOrReturnDefaultValue("LIST_SCAN_MAX", "1000"))
+	config.ExpandWorkers = cast.ToInt(getOrReturnDefaultValue("EXPAND_WORKERS", "8"))
+
+	config.ArticleListQueries = cast.ToBool(getOrReturnDefaultValue("ARTICLE_LIST_QUERIES", "false"))
+
+	config.CursorSecret = cast.ToString(getOrReturnDefaultValue("CURSOR_SECRET", ""))
+	config.CursorTTL = cast.ToDuration(getOrReturnDefaultValue("CURSOR_TTL", "24h"))
+
+	config.PublicURL = cast.ToString(getOrReturnDefaultValue("PUBLIC_URL", ""))
+	config.FeedTitle = cast.ToString(getOrReturnDefaultValue("FEED_TITLE", "Blogpost"))
+	config.FeedSize = cast.ToInt(getOrReturnDefaultValue("FEED_SIZE", "20"))
+	config.FeedTTL = cast.ToDuration(getOrReturnDefaultValue("FEED_TTL", "5m"))
+
+	config.ExcerptLength = cast.ToInt(getOrReturnDefaultValue("EXCERPT_LENGTH", "200"))
+	config.WordsPerMinute = cast.ToInt(getOrReturnDefaultValue("WORDS_PER_MINUTE", "200"))
+
+	config.CompressEncodings = cast.ToString(getOrReturnDefaultValue("COMPRESS_ENCODINGS", "zstd,br,gzip"))
+	config.CompressMinSize = cast.ToInt(getOrReturnDefaultValue("COMPRESS_MIN_SIZE", "1024"))
+	config.CompressExcludedTypes = cast.ToString(getOrReturnDefaultValue("COMPRESS_EXCLUDED_TYPES",
+		"image/,video/,audio/,font/woff,text/event-stream,application/gzip,application/zip,application/zstd,application/x-brotli"))
+
+	config.EventsReplaySize = cast.ToInt(getOrReturnDefaultValue("EVENTS_REPLAY_SIZE", "1000"))
+	config.EventsHeartbeat = cast.ToDuration(getOrReturnDefaultValue("EVENTS_HEARTBEAT", "15s"))
+
+	config.LegacyRoutesDeprecatedAt = cast.ToString(getOrReturnDefaultValue("LEGACY_ROUTES_DEPRECATED_AT", "2026-10-19"))
+	config.LegacyRoutesSunset = cast.ToString(getOrReturnDefaultValue("LEGACY_ROUTES_SUNSET", "2027-04-19"))
+
+	config.CacheStore = cast.ToString(getOrReturnDefaultValue("CACHE_STORE", "memory"))
+	config.CacheMaxEntries = cast.ToInt(getOrReturnDefaultValue("CACHE_MAX_ENTRIES", "10000"))
+	config.CacheArticleTTL = cast.ToDuration(getOrReturnDefaultValue("CACHE_ARTICLE_TTL", "30s"))
+	config.CacheArticleListTTL = cast.ToDuration(getOrReturnDefaultValue("CACHE_ARTICLE_LIST_TTL", "10s"))
+	config.CacheAuthorTTL = cast.ToDuration(getOrReturnDefaultValue("CACHE_AUTHOR_TTL", "60s"))
+	config.CacheAuthorListTTL = cast.ToDuration(getOrReturnDefaultValue("CACHE_AUTHOR_LIST_TTL", "30s"))
+	config.CacheStaleWhileRevalidate = cast.ToDuration(getOrReturnDefaultValue("CACHE_STALE_WHILE_REVALIDATE", "30s"))
+	config.CacheStaleIfError = cast.ToDuration(getOrReturnDefaultValue("CACHE_STALE_IF_ERROR", "10m"))
+
+	config.RedisAddr = cast.ToString(getOrReturnDefaultValue("REDIS_ADDR", "localhost:6379"))
+	config.RedisPassword = cast.ToString(getOrReturnDefaultValue("REDIS_PASSWORD", ""))
+	config.RedisDB = cast.ToInt(getOrReturnDefaultValue("REDIS_DB", "0"))
 
 	config.AuthorServiceGrpcHost = cast.ToString(getOrReturnDefaultValue("AUTHOR_SERVICE_GRPC_HOST", "localhost"))
 	config.AuthorServiceGrpcPort = cast.ToString(getOrReturnDefaultValue("AUTHOR_SERVICE_GRPC_PORT", ":9000"))
@@ -53,6 +149,19 @@ func Load() Config {
 
 	config.AuthorizationServiceGrpcHost = cast.ToString(getOrReturnDefaultValue("AUTHORIZATION_SERVICE_GRPC_HOST", "localhost"))
 	config.AuthorizationServiceGrpcPort = cast.ToString(getOrReturnDefaultValue("AUTHORIZATION_SERVICE_GRPC_PORT", ":9002"))
+
+	config.ImportMaxBytes = cast.ToInt64(getOrReturnDefaultValue("IMPORT_MAX_BYTES", "10485760"))
+	config.ImportMaxRecords = cast.ToInt(getOrReturnDefaultValue("IMPORT_MAX_RECORDS", "1000"))
+	config.ImportWorkers = cast.ToInt(getOrReturnDefaultValue("IMPORT_WORKERS", "4"))
+
+	config.BatchMaxOperations = cast.ToInt(getOrReturnDefaultValue("BATCH_MAX_OPERATIONS", "50"))
+	config.BatchConcurrency = cast.ToInt(getOrReturnDefaultValue("BATCH_CONCURRENCY", "4"))
+
+	config.GraphQLMaxDepth = cast.ToInt(getOrReturnDefaultValue("GRAPHQL_MAX_DEPTH", "8"))
+	config.GraphQLMaxComplexity = cast.ToInt(getOrReturnDefaultValue("GRAPHQL_MAX_COMPLEXITY", "1000"))
+
+	config.GrpcCoalesceReads = cast.ToBool(getOrReturnDefaultValue("GRPC_COALESCE_READS", "true"))
+	config.GrpcTimeout = cast.ToDuration(getOrReturnDefaultValue("GRPC_TIMEOUT", "5s"))
 	return config
 }
 
@@ -0,0 +1,197 @@
+package events
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Types of the changes the gateway publishes
+const (
+	ArticleCreated = "article.created"
+	ArticleUpdated = "article.updated"
+	ArticleDeleted = "article.deleted"
+	AuthorCreated  = "author.created"
+	AuthorUpdated  = "author.updated"
+	AuthorDeleted  = "author.deleted"
+)
+
+// Types lists every event type
+var Types = []string{ArticleCreated, ArticleUpdated, ArticleDeleted, AuthorCreated, AuthorUpdated, AuthorDeleted}
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+const subscriberBuffer = 64
+
+// Event is one change that went through the gateway
+type Event struct {
+	ID       uint64 // sequence number within the broker's epoch, clients see it through Broker.FormatID
+	Type     string
+	Data     []byte // the changed resource as JSON
+	AuthorID string // the author the change belongs to, the author itself for author events
+	UserID   string // the user who made the change
+}
+
+// Filter selects the events one subscriber receives, zero values select everything
+type Filter struct {
+	Types    []string // event types, or the resource name such as article for all of its events
+	AuthorID string
+	UserID   string // only changes made by this user
+	NotUser  string // no changes made by this user
+}
+
+// Match reports whether e passes the filter
+func (f Filter) Match(e Event) bool {
+	if f.AuthorID != "" && e.AuthorID != f.AuthorID {
+		return false
+	}
+	if f.UserID != "" && e.UserID != f.UserID {
+		return false
+	}
+	if f.NotUser != "" && e.UserID == f.NotUser {
+		return false
+	}
+	if len(f.Types) == 0 {
+		return true
+	}
+	for _, t := range f.Types {
+		if t == e.Type || strings.HasPrefix(e.Type, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// Broker hands published events to every subscriber and keeps the latest ones in memory, so a client
+// that reconnects can be sent what it missed. Nothing is shared between gateway instances, event ids
+// carry the epoch of the broker that issued them so one from another instance or run is never mistaken
+// for one of this broker's
+type Broker struct {
+	epoch       string
+	mu          sync.Mutex
+	lastID      uint64
+	replay      []Event // ring of the latest events, oldest at start
+	start       int
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroker keeps the latest replaySize events for resuming clients
+func NewBroker(replaySize int) *Broker {
+	if replaySize < 0 {
+		replaySize = 0
+	}
+	return &Broker{
+		epoch:       strconv.FormatInt(time.Now().UnixNano(), 36),
+		replay:      make([]Event, 0, replaySize),
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// FormatID is the id a client is sent for the event with sequence number id
+func (b *Broker) FormatID(id uint64) string {
+	return b.epoch + "-" + strconv.FormatUint(id, 10)
+}
+
+// ParseID reads an id made by FormatID, ok is false when it is malformed or was issued by another epoch
+func (b *Broker) ParseID(s string) (id uint64, ok bool) {
+	i := strings.LastIndexByte(s, '-')
+	if i < 0 || s[:i] != b.epoch {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(s[i+1:], 10, 64)
+	return id, err == nil
+}
+
+// Publish sends a change to every subscriber, data is encoded as JSON. A subscriber too slow to take
+// it is dropped, it gets the event from the replay buffer when it resumes
+func (b *Broker) Publish(eventType, authorID, userID string, data interface{}) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastID++
+	e := Event{
+		ID:       b.lastID,
+		Type:     eventType,
+		Data:     encoded,
+		AuthorID: authorID,
+		UserID:   userID,
+	}
+	switch {
+	case cap(b.replay) == 0:
+	case len(b.replay) < cap(b.replay):
+		b.replay = append(b.replay, e)
+	default:
+		b.replay[b.start] = e
+		b.start = (b.start + 1) % len(b.replay)
+	}
+
+	for s := range b.subscribers {
+		select {
+		case s.events <- e:
+		default:
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+// Subscribe starts a subscription. With resume the events after lastID are returned to be sent first,
+// complete is false when some of them already left the replay buffer or lastID was never issued,
+// so the client has to reload what it shows. Ids of other epochs are rejected by ParseID before they get here
+func (b *Broker) Subscribe(lastID uint64, resume bool) (missed []Event, s *Subscription, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s = &Subscription{Latest: b.lastID, events: make(chan Event, subscriberBuffer), broker: b}
+	b.subscribers[s] = struct{}{}
+	if !resume {
+		return nil, s, true
+	}
+	if lastID > b.lastID {
+		return nil, s, false
+	}
+
+	oldest := b.lastID + 1
+	if len(b.replay) > 0 {
+		oldest = b.replay[b.start].ID
+	}
+	if lastID+1 < oldest {
+		return nil, s, false
+	}
+	for i := range b.replay {
+		if e := b.replay[(b.start+i)%len(b.replay)]; e.ID > lastID {
+			missed = append(missed, e)
+		}
+	}
+	return missed, s, true
+}
+
+// Subscription receives the events published after it started
+type Subscription struct {
+	Latest uint64 // id of the last event published before the subscription started
+
+	events chan Event
+	broker *Broker
+}
+
+// Events is closed when the subscriber fell behind or the subscription was closed
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
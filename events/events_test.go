@@ -0,0 +1,53 @@
+package events
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrokerIDsCarryTheEpoch(t *testing.T) {
+	b := NewBroker(10)
+	b.Publish(ArticleCreated, "a", "u", map[string]string{"id": "1"})
+
+	_, sub, _ := b.Subscribe(0, false)
+	defer sub.Close()
+	formatted := b.FormatID(sub.Latest)
+	if !strings.HasPrefix(formatted, b.epoch+"-") {
+		t.Fatalf("FormatID(%d) = %q, want the epoch %q as prefix", sub.Latest, formatted, b.epoch)
+	}
+
+	id, ok := b.ParseID(formatted)
+	if !ok || id != sub.Latest {
+		t.Errorf("ParseID(%q) = %d, %v, want %d, true", formatted, id, ok, sub.Latest)
+	}
+}
+
+func TestParseIDRejectsOtherEpochs(t *testing.T) {
+	b := NewBroker(10)
+	restarted := &Broker{epoch: b.epoch + "x"}
+
+	for _, id := range []string{"1", "", "-1", b.epoch, b.epoch + "-", b.epoch + "-x", restarted.FormatID(1)} {
+		if _, ok := b.ParseID(id); ok {
+			t.Errorf("ParseID(%q) accepted an id this broker did not issue", id)
+		}
+	}
+}
+
+func TestSubscribeReplaysMissedEvents(t *testing.T) {
+	b := NewBroker(2)
+	for i := 0; i < 3; i++ {
+		b.Publish(AuthorUpdated, "a", "u", i)
+	}
+
+	missed, sub, complete := b.Subscribe(2, true)
+	sub.Close()
+	if !complete || len(missed) != 1 || missed[0].ID != 3 {
+		t.Errorf("Subscribe(2) = %v, complete %v, want event 3", missed, complete)
+	}
+
+	_, sub, complete = b.Subscribe(0, true)
+	sub.Close()
+	if complete {
+		t.Error("Subscribe(0) is complete although event 1 left the replay buffer")
+	}
+}
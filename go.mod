@@ -10,8 +10,19 @@ require (
 )
 
 require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/evanphx/json-patch/v5 v5.6.0
 	github.com/gin-gonic/gin v1.8.1
+	github.com/go-playground/validator/v10 v10.10.0
+	github.com/google/uuid v1.3.0
+	github.com/graphql-go/graphql v0.8.1
+	github.com/klauspost/compress v1.17.9
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/redis/go-redis/v9 v9.0.5
 	github.com/swaggo/files v0.0.0-20220728132757-551d4a08d97a
 	github.com/swaggo/gin-swagger v1.5.3
 	github.com/swaggo/swag v1.8.8
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	github.com/yuin/goldmark v1.4.13
+	golang.org/x/sync v0.7.0
 )
@@ -2,10 +2,12 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
+	"blogpost/events"
 	"blogpost/genprotos/article"
+	"blogpost/genprotos/author"
 	"blogpost/models"
+	"blogpost/response"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,18 +20,16 @@ import (
 // @Produce     json
 // @Param       article       body     models.CreateArticleModel true  "article body"
 // @Param       Authorization header   string                    false "Authorization"
-// @Success     201           {object} models.JSONResponse{data=models.Article}
-// @Failure     400           {object} models.JSONErrorResponse
+// @Success     201           {object} models.JSONResponse{data=models.PackedArticleModel}
+// @Failure     400           {object} models.Problem
+// @Failure     422           {object} models.Problem
 // @Router      /v1/article [post]
 func (h Handler) CreateArticle(c *gin.Context) {
 	var body models.CreateArticleModel
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: err.Error()})
+	if !bindBody(c, &body) {
 		return
 	}
 
-	// TODO - validation should be here
-
 	obj, err := h.grpcClients.Article.AddArticle(c.Request.Context(), &article.AddArticleReq{
 		AuthorId: body.AuthorID,
 		Content: &article.AddArticleReq_Post{
@@ -38,9 +38,7 @@ func (h Handler) CreateArticle(c *gin.Context) {
 		},
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{
-			Error: err.Error(),
-		})
+		response.GRPCError(c, err)
 		return
 	}
 
@@ -48,16 +46,16 @@ func (h Handler) CreateArticle(c *gin.Context) {
 		Id: obj.Id,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: "getarticleErr",
-		})
+		response.GRPCError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.JSONResponse{
-		Message: "Article | Created",
-		Data:    article,
-	})
+	created := models.NewPackedArticle(article)
+	h.publish(events.ArticleCreated, created.Author.ID, c.GetString("auth_user_id"), created)
+
+	c.Header("ETag", articleETag(article))
+	response.SetProto(c, article)
+	response.OK(c, http.StatusCreated, "Article | Created", created)
 }
 
 // GetArticleByID godoc
@@ -66,30 +64,55 @@ func (h Handler) CreateArticle(c *gin.Context) {
 // @Tags        articles
 // @Accept      json
 // @Param       id            path   string true  "Article ID"
+// @Param       If-None-Match header string false "ETag of a cached copy"
+// @Param       format        query  string false "raw (default) or html to add the rendered body, excerpt, word count and reading time"
+// @Param       fields        query  string false "comma separated fields to return, e.g. id,title,author.fullname"
 // @Param       Authorization header string false "Authorization"
 // @Produce     json
 // @Success     200 {object} models.JSONResponse{data=models.PackedArticleModel}
-// @Failure     400 {object} models.JSONErrorResponse
+// @Header      200 {string} ETag "entity tag of the article"
+// @Success     304 {string} string "Not Modified"
+// @Failure     400 {object} models.Problem
+// @Failure     404 {object} models.Problem
+// @Failure     422 {object} models.Problem
 // @Router      /v1/article/{id} [get]
 func (h Handler) GetArticleByID(c *gin.Context) {
-	idStr := c.Param("id")
-
-	// TODO - validation
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+	html, err := parseRenderFormat(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	article, err := h.grpcClients.Article.GetArticleByID(c.Request.Context(), &article.GetArticleByIdReq{
 		Id: idStr,
 	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.JSONErrorResponse{
-			Error: err.Error(),
-		})
+		response.GRPCError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.JSONResponse{
-		Message: "OK",
-		Data:    article,
-	})
+	etag := articleETag(article)
+	if html {
+		etag = variantETag(etag, "html")
+	}
+	if notModified(c, etag) {
+		return
+	}
+
+	packed := models.NewPackedArticle(article)
+	if html {
+		if packed.Rendered, err = h.render(packed.Body); err != nil {
+			response.Error(c, http.StatusInternalServerError, err.Error())
+			return
+		}
+	} else {
+		response.SetProto(c, article)
+	}
+	response.OK(c, http.StatusOK, "OK", packed)
 }
 
 // GetArticleList godoc
@@ -98,81 +121,232 @@ func (h Handler) GetArticleByID(c *gin.Context) {
 // @Tags        articles
 // @Accept      json
 // @Produce     json
-// @Param       offset        query    int    false "0"
-// @Param       limit         query    int    false "10"
-// @Param       search        query    string false "search"
-// @Param       Authorization header   string false "Authorization"
-// @Success     200           {object} models.JSONResponse{data=[]models.Article}
+// @Param       cursor         query    string false "opaque cursor from a next or prev link"
+// @Param       offset         query    int    false "0, ignored with cursor"
+// @Param       limit          query    int    false "10, capped at the configured maximum"
+// @Param       search         query    string false "search"
+// @Param       sort           query    string false "created_at, updated_at or title"
+// @Param       order          query    string false "asc or desc"
+// @Param       author_id      query    string false "only articles of this author"
+// @Param       created_after  query    string false "date or RFC 3339 time"
+// @Param       created_before query    string false "date or RFC 3339 time"
+// @Param       filter         query    string false "clauses joined by and, e.g. created_at ge 2024-01-01 and title contains go"
+// @Param       expand         query    string false "author, returns models.PackedArticleModel items"
+// @Param       format         query    string false "raw (default) or html to add the rendered body, excerpt, word count and reading time"
+// @Param       fields         query    string false "comma separated fields to return, e.g. id,title,author.fullname"
+// @Param       Authorization  header   string false "Authorization"
+// @Success     200            {object} models.JSONResponse{data=[]models.Article}
+// @Header      200            {string} Link "RFC 8288 next and prev links"
+// @Failure     400            {object} models.Problem
 // @Router      /v1/article [get]
 func (h Handler) GetArticleList(c *gin.Context) {
-	offsetStr := c.DefaultQuery("offset", h.Conf.DefaultOffset)
-	limitStr := c.DefaultQuery("limit", h.Conf.DefaultLimit)
-	searchStr := c.DefaultQuery("search", "")
-	offset, err := strconv.Atoi(offsetStr)
+	p, err := h.parsePage(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{
-			Error: "offset error",
-		})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	limit, err := strconv.Atoi(limitStr)
+	q, err := parseArticleQuery(c)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{
-			Error: "limit error",
-		})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
-	articleList, err := h.grpcClients.Article.GetArticleList(c.Request.Context(), &article.GetArticleListReq{
-		Offset: int32(offset),
-		Limit:  int32(limit),
-		Search: searchStr,
-	})
+	searchStr := c.DefaultQuery("search", "")
+
+	req, complete := q.listRequest(searchStr)
+	if !complete || !q.empty() && !h.Conf.ArticleListQueries {
+		h.queryArticleList(c, p, q, req)
+		return
+	}
+
+	// one article past the page tells whether there is a next one
+	req.Offset = int32(p.Offset)
+	req.Limit = int32(lookahead(p.Limit))
+	articleList, err := h.grpcClients.Article.GetArticleList(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: err.Error(),
+		response.GRPCError(c, err)
+		return
+	}
+
+	articles, hasNext := trimPage(len(articleList.GetArticles()), p.Limit)
+	list := models.NewArticleList(articleList.GetArticles()[:articles])
+	data, ok := h.expandArticles(c, list)
+	if !ok {
+		return
+	}
+	if storedArticles(c) {
+		response.SetProto(c, &article.GetArticleListRes{Articles: articleList.GetArticles()[:articles]})
+	}
+	h.writePage(c, "OK", data, p, len(list), hasNext, nil)
+}
+
+// queryArticleList sorts and filters at the gateway, reading the articles of one author when the query
+// is scoped to an author and every article matching req otherwise
+func (h Handler) queryArticleList(c *gin.Context, p page, q articleQuery, req *article.GetArticleListReq) {
+	var (
+		list []models.Article
+		err  error
+	)
+	if authorID, ok := q.authorScope(); ok && req.GetSearch() == "" {
+		var articles *author.GetArticles
+		articles, err = h.grpcClients.Author.GetArticlesByAuthorID(c.Request.Context(), &author.Id{
+			Id: authorID,
 		})
+		list = models.NewArticleListFromAuthorArticles(articles.GetArticles())
+	} else {
+		list, err = h.scanArticles(c.Request.Context(), req)
+	}
+	if err == errTooManyArticles {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		response.GRPCError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.JSONResponse{
-		Message: "OK",
-		Data:    articleList,
-	})
+	list = q.apply(list)
+	total := len(list)
+	page := pageArticles(list, p.Offset, p.Limit)
+	data, ok := h.expandArticles(c, page)
+	if !ok {
+		return
+	}
+	h.writePage(c, "OK", data, p, len(page), p.Offset+len(page) < total, &total)
 }
 
 // UpdateArticle godoc
 // @Summary     update article
-// @Description update a new article
+// @Description update a new article, deprecated in favour of PUT /v1/article/{id}
 // @Tags        articles
 // @Accept      json
 // @Produce     json
 // @Param       article       body     models.UpdateArticleModel true  "article body"
+// @Param       If-Match      header   string                    false "ETag the update is based on"
 // @Param       Authorization header   string                    false "Authorization"
-// @Success     200           {object} models.JSONResponse{data=[]models.Article}
-// @Response    400           {object} models.JSONErrorResponse
+// @Success     200           {object} models.JSONResponse{data=models.PackedArticleModel}
+// @Response    400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Deprecated
 // @Router      /v1/article [put]
 func (h Handler) UpdateArticle(c *gin.Context) {
 	var body models.UpdateArticleModel
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: err.Error()})
+	if !bindBody(c, &body) {
 		return
 	}
+	if !h.articleIfMatch(c, body.ID) {
+		return
+	}
+
+	h.updateArticle(c, body.ID, body.Content)
+}
+
+// UpdateArticleByID godoc
+// @Summary     update article by id
+// @Description replace the content of an article
+// @Tags        articles
+// @Accept      json
+// @Produce     json
+// @Param       id            path     string                        true  "Article ID"
+// @Param       article       body     models.UpdateArticleByIDModel true  "article body"
+// @Param       If-Match      header   string                        false "ETag the update is based on"
+// @Param       Authorization header   string                        false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=models.PackedArticleModel}
+// @Response    400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/article/{id} [put]
+func (h Handler) UpdateArticleByID(c *gin.Context) {
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var body models.UpdateArticleByIDModel
+	if !bindBody(c, &body) {
+		return
+	}
+	if body.ID != "" && !checkBodyID(c, idStr, body.ID) {
+		return
+	}
+	if !h.articleIfMatch(c, idStr) {
+		return
+	}
+
+	h.updateArticle(c, idStr, body.Content)
+}
+
+// updateArticle stores content and answers with the updated article
+func (h Handler) updateArticle(c *gin.Context, id string, content models.Content) {
 	updated, err := h.grpcClients.Article.UpdateArticle(c.Request.Context(), &article.UpdateArticleReq{
-		Id: body.ID,
+		Id: id,
 		Content: &article.UpdateArticleReq_Post{
-			Title: body.Title,
-			Body:  body.Body,
+			Title: content.Title,
+			Body:  content.Body,
 		},
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: err.Error()})
+		response.GRPCError(c, err)
+		return
+	}
+
+	packed := models.NewUpdatedArticle(updated)
+	h.publish(events.ArticleUpdated, packed.Author.ID, c.GetString("auth_user_id"), packed)
+
+	c.Header("ETag", makeETag(updated.GetId(), updated.GetUpdatedAt(), updated.GetCreatedAt()))
+	response.SetProto(c, updated)
+	response.OK(c, http.StatusOK, "Article | Update", packed)
+}
+
+// PatchArticle godoc
+// @Summary     patch article
+// @Description partially update an article with a JSON Merge Patch or a JSON Patch
+// @Tags        articles
+// @Accept      application/merge-patch+json
+// @Accept      application/json-patch+json
+// @Produce     json
+// @Param       id            path     string true  "Article ID"
+// @Param       patch         body     object true  "merge patch object or array of patch operations"
+// @Param       If-Match      header   string false "ETag the patch is based on"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=models.PackedArticleModel}
+// @Failure     400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     415           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/article/{id} [patch]
+func (h Handler) PatchArticle(c *gin.Context) {
+	idStr, ok := pathID(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"data":    updated,
-		"message": "Article | Update",
+	current, err := h.grpcClients.Article.GetArticleByID(c.Request.Context(), &article.GetArticleByIdReq{
+		Id: idStr,
 	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+	if !ifMatch(c, articleETag(current)) {
+		return
+	}
+
+	var body models.UpdateArticleModel
+	if !applyPatch(c, models.UpdateArticleModel{
+		ID: current.GetId(),
+		Content: models.Content{
+			Title: current.GetContent().GetTitle(),
+			Body:  current.GetContent().GetBody(),
+		},
+	}, &body) {
+		return
+	}
+	if !checkBodyID(c, idStr, body.ID) {
+		return
+	}
+
+	h.updateArticle(c, idStr, body.Content)
 }
 
 // DeleteArticle godoc
@@ -181,25 +355,33 @@ func (h Handler) UpdateArticle(c *gin.Context) {
 // @Tags        articles
 // @Accept      json
 // @Param       id            path   string true  "Article ID"
+// @Param       If-Match      header string false "ETag the deletion is based on"
 // @Param       Authorization header string false "Authorization"
 // @Produce     json
 // @Success     200 {object} models.JSONResponse{data=models.DeleteArticleModel}
-// @Failure     404 {object} error
+// @Failure     404 {object} models.Problem
+// @Failure     412 {object} models.Problem
+// @Failure     422 {object} models.Problem
 // @Router      /v1/article/{id} [delete]
 func (h Handler) DeleteArticle(c *gin.Context) {
-	idStr := c.Param("id")
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+	if !h.articleIfMatch(c, idStr) {
+		return
+	}
+
 	article, err := h.grpcClients.Article.DeleteArticle(c.Request.Context(), &article.DeleteArticleReq{
 		Id: idStr,
 	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "Article | Delete | NOT FOUND",
-			"data":    nil,
-		})
+		response.GRPCError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Article deleted",
-		"data":    article,
-	})
+
+	deleted := models.NewDeletedArticle(article)
+	h.publish(events.ArticleDeleted, deleted.AuthorID, c.GetString("auth_user_id"), deleted)
+
+	response.OK(c, http.StatusOK, "Article deleted", deleted)
 }
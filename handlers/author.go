@@ -1,48 +1,47 @@
 package handlers
 
 import (
+	"net/http"
+
+	"blogpost/events"
 	"blogpost/genprotos/author"
 	"blogpost/models"
-	"net/http"
-	"strconv"
+	"blogpost/response"
 
 	"github.com/gin-gonic/gin"
 )
 
 // CreateAuthor godoc
 // @Summary     Create author
-// @Description create a new author
+// @Description create a new author, the author service does not return it
 // @Tags        authors
 // @Accept      json
 // @Produce     json
 // @Param       author        body     models.CreateAuthorModel true  "author body"
 // @Param       Authorization header   string                   false "Authorization"
-// @Success     201           {object} models.JSONResponse{data=models.Author}
-// @Failure     400           {object} models.JSONErrorResponse
+// @Success     201           {object} models.JSONResponse
+// @Failure     400           {object} models.Problem
+// @Failure     422           {object} models.Problem
 // @Router      /v1/author [post]
 func (h Handler) CreateAuthor(c *gin.Context) {
 	var body models.CreateAuthorModel
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: err.Error()})
+	if !bindBody(c, &body) {
 		return
 	}
 
-	// TODO - validation should be here
-
-	author, err := h.grpcClients.Author.AddAuthor(c.Request.Context(), &author.CreateAuthorReq{
+	res, err := h.grpcClients.Author.AddAuthor(c.Request.Context(), &author.CreateAuthorReq{
 		Fullname: body.Fullname,
 	})
-
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{
-			Error: err.Error(),
-		})
+		response.GRPCError(c, err)
 		return
 	}
-	c.JSON(http.StatusCreated, models.JSONResponse{
-		Message: "Author | Created",
-		Data:    author,
-	})
+
+	// the author service answers with an empty message, so the new author's id is not known here
+	h.publish(events.AuthorCreated, "", c.GetString("auth_user_id"), models.Author{Fullname: body.Fullname})
+
+	response.SetProto(c, res)
+	response.OK(c, http.StatusCreated, "Author | Created", nil)
 }
 
 // GetAuthorByID godoc
@@ -51,28 +50,116 @@ func (h Handler) CreateAuthor(c *gin.Context) {
 // @Tags        authors
 // @Accept      json
 // @Param       id            path   string true  "Author ID"
+// @Param       include       query  string false "articles, returns models.AuthorWithArticles"
+// @Param       If-None-Match header string false "ETag of a cached copy, ignored with include"
+// @Param       fields        query  string false "comma separated fields to return, e.g. id,fullname,articles.title"
 // @Param       Authorization header string false "Authorization"
 // @Produce     json
 // @Success     200 {object} models.JSONResponse{data=models.Author}
-// @Failure     400 {object} models.JSONErrorResponse
+// @Header      200 {string} ETag "entity tag of the author"
+// @Success     304 {string} string "Not Modified"
+// @Failure     400 {object} models.Problem
+// @Failure     404 {object} models.Problem
+// @Failure     422 {object} models.Problem
 // @Router      /v1/author/{id} [get]
 func (h Handler) GetAuthorByID(c *gin.Context) {
-	idStr := c.Param("id")
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+	include := c.DefaultQuery("include", "")
+	if include != "" && include != "articles" {
+		response.Error(c, http.StatusBadRequest, "include error")
+		return
+	}
 
-	// TODO - validation
-	author, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+	found, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
 		Id: idStr,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: err.Error(),
+		response.GRPCError(c, err)
+		return
+	}
+
+	if include == "articles" {
+		articles, err := h.grpcClients.Author.GetArticlesByAuthorID(c.Request.Context(), &author.Id{
+			Id: idStr,
 		})
+		if err != nil {
+			response.GRPCError(c, err)
+			return
+		}
+
+		found.Articles = articles.GetArticles()
+		response.SetProto(c, found)
+		response.OK(c, http.StatusOK, "OK", models.NewAuthorWithArticles(found, articles.GetArticles()))
+		return
+	}
+
+	if notModified(c, authorETag(found)) {
+		return
+	}
+
+	response.SetProto(c, found)
+	response.OK(c, http.StatusOK, "OK", models.NewAuthorFromRes(found))
+}
+
+// GetAuthorArticles godoc
+// @Summary     List author articles
+// @Description get articles of an author
+// @Tags        authors
+// @Accept      json
+// @Produce     json
+// @Param       id            path     string true  "Author ID"
+// @Param       offset        query    int    false "0"
+// @Param       limit         query    int    false "10"
+// @Param       sort          query    string false "created_at, updated_at or title"
+// @Param       order         query    string false "asc or desc"
+// @Param       expand        query    string false "author, returns models.PackedArticleModel items"
+// @Param       format        query    string false "raw (default) or html to add the rendered body, excerpt, word count and reading time"
+// @Param       fields        query    string false "comma separated fields to return, e.g. id,title,author.fullname"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=[]models.Article}
+// @Failure     400           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/author/{id}/articles [get]
+func (h Handler) GetAuthorArticles(c *gin.Context) {
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+	offset, limit, err := h.parseOffsetLimit(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.JSONResponse{
-		Message: "Author | Created",
-		Data:    author,
+	// the service returns every article of the author, so sorting and paging happen here
+	articles, err := h.grpcClients.Author.GetArticlesByAuthorID(c.Request.Context(), &author.Id{
+		Id: idStr,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	list := models.NewArticleListFromAuthorArticles(articles.GetArticles())
+	if err := sortArticles(list, c.Query("sort"), c.Query("order")); err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	total := len(list)
+	page := pageArticles(list, offset, limit)
+	data, ok := h.expandArticles(c, page)
+	if !ok {
+		return
+	}
+	response.List(c, "OK", data, models.Pagination{
+		Offset: offset,
+		Limit:  limit,
+		Count:  len(page),
+		Total:  &total,
 	})
 }
 
@@ -82,78 +169,176 @@ func (h Handler) GetAuthorByID(c *gin.Context) {
 // @Tags        authors
 // @Accept      json
 // @Produce     json
-// @Param       offset        query    int    false "0"
-// @Param       limit         query    int    false "10"
+// @Param       cursor        query    string false "opaque cursor from a next or prev link"
+// @Param       offset        query    int    false "0, ignored with cursor"
+// @Param       limit         query    int    false "10, capped at the configured maximum"
 // @Param       search        query    string false "search"
+// @Param       fields        query    string false "comma separated fields to return, e.g. id,fullname"
 // @Param       Authorization header   string false "Authorization"
 // @Success     200           {object} models.JSONResponse{data=[]models.Author}
+// @Header      200           {string} Link "RFC 8288 next and prev links"
+// @Failure     400           {object} models.Problem
 // @Router      /v1/author [get]
 func (h Handler) GetAuthorList(c *gin.Context) {
-	offsetStr := c.DefaultQuery("offset", h.Conf.DefaultOffset)
-	limitStr := c.DefaultQuery("limit", h.Conf.DefaultLimit)
-	search := c.DefaultQuery("search", "")
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: "offset error",
-		})
-		return
-	}
-	limit, err := strconv.Atoi(limitStr)
+	p, err := h.parsePage(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: "limit error",
-		})
+		response.Error(c, http.StatusBadRequest, err.Error())
 		return
 	}
+	search := c.DefaultQuery("search", "")
+
 	authorList, err := h.grpcClients.Author.GetAuthorList(c.Request.Context(), &author.GetAuthorListReq{
-		Offset: int64(offset),
-		Limit:  int64(limit),
+		Offset: int64(p.Offset),
+		Limit:  int64(lookahead(p.Limit)),
 		Search: search,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: err.Error(),
-		})
+		response.GRPCError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.JSONResponse{
-		Message: "OK",
-		Data:    authorList,
-	})
+	authors, hasNext := trimPage(len(authorList.GetAuthors()), p.Limit)
+	list := models.NewAuthorList(authorList.GetAuthors()[:authors])
+	response.SetProto(c, &author.GetAuthors{Authors: authorList.GetAuthors()[:authors]})
+	h.writePage(c, "OK", list, p, len(list), hasNext, nil)
 }
 
 // UpdateAuthor godoc
 // @Summary     update author
-// @Description update a new author
+// @Description update a new author, deprecated in favour of PUT /v1/author/{id}
 // @Tags        authors
 // @Accept      json
 // @Produce     json
 // @Param       author        body     models.UpdateAuthorModel true  "author body"
+// @Param       If-Match      header   string                   false "ETag the update is based on"
 // @Param       Authorization header   string                   false "Authorization"
 // @Success     200           {object} models.JSONResponse{data=models.Author}
-// @Response    400           {object} models.JSONErrorResponse
+// @Response    400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Deprecated
 // @Router      /v1/author [put]
 func (h Handler) UpdateAuthor(c *gin.Context) {
 	var body models.UpdateAuthorModel
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: err.Error()})
+	if !bindBody(c, &body) {
 		return
 	}
-	updated, err := h.grpcClients.Author.UpdateAuthor(c.Request.Context(), &author.UpdateAuthorReq{
-		Id:       body.ID,
-		Fullname: body.Fullname,
+	if !h.authorIfMatch(c, body.ID) {
+		return
+	}
+
+	h.updateAuthor(c, body.ID, body.Fullname)
+}
+
+// UpdateAuthorByID godoc
+// @Summary     update author by id
+// @Description replace the fields of an author
+// @Tags        authors
+// @Accept      json
+// @Produce     json
+// @Param       id            path     string                       true  "Author ID"
+// @Param       author        body     models.UpdateAuthorByIDModel true  "author body"
+// @Param       If-Match      header   string                       false "ETag the update is based on"
+// @Param       Authorization header   string                       false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=models.Author}
+// @Response    400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/author/{id} [put]
+func (h Handler) UpdateAuthorByID(c *gin.Context) {
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	var body models.UpdateAuthorByIDModel
+	if !bindBody(c, &body) {
+		return
+	}
+	if body.ID != "" && !checkBodyID(c, idStr, body.ID) {
+		return
+	}
+	if !h.authorIfMatch(c, idStr) {
+		return
+	}
+
+	h.updateAuthor(c, idStr, body.Fullname)
+}
+
+// updateAuthor stores fullname and answers with the updated author
+func (h Handler) updateAuthor(c *gin.Context, id, fullname string) {
+	_, err := h.grpcClients.Author.UpdateAuthor(c.Request.Context(), &author.UpdateAuthorReq{
+		Id:       id,
+		Fullname: fullname,
 	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{
-			Error: "Faild update!"})
+		response.GRPCError(c, err)
+		return
+	}
+
+	// UpdateAuthor replies with an empty message, so the author is read back
+	updated, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+		Id: id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	a := models.NewAuthorFromRes(updated)
+	h.publish(events.AuthorUpdated, a.ID, c.GetString("auth_user_id"), a)
+
+	c.Header("ETag", authorETag(updated))
+	response.SetProto(c, updated)
+	response.OK(c, http.StatusOK, "author | Update", a)
+}
+
+// PatchAuthor godoc
+// @Summary     patch author
+// @Description partially update an author with a JSON Merge Patch or a JSON Patch
+// @Tags        authors
+// @Accept      application/merge-patch+json
+// @Accept      application/json-patch+json
+// @Produce     json
+// @Param       id            path     string true  "Author ID"
+// @Param       patch         body     object true  "merge patch object or array of patch operations"
+// @Param       If-Match      header   string false "ETag the patch is based on"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=models.Author}
+// @Failure     400           {object} models.Problem
+// @Failure     412           {object} models.Problem
+// @Failure     415           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/author/{id} [patch]
+func (h Handler) PatchAuthor(c *gin.Context) {
+	idStr, ok := pathID(c)
+	if !ok {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"data":    updated,
-		"message": "author | Update",
+
+	current, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+		Id: idStr,
 	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+	if !ifMatch(c, authorETag(current)) {
+		return
+	}
+
+	var body models.UpdateAuthorModel
+	if !applyPatch(c, models.UpdateAuthorModel{
+		ID:       current.GetId(),
+		Fullname: current.GetFullname(),
+	}, &body) {
+		return
+	}
+	if !checkBodyID(c, idStr, body.ID) {
+		return
+	}
+
+	h.updateAuthor(c, idStr, body.Fullname)
 }
 
 // DeleteAuthor godoc
@@ -162,23 +347,42 @@ func (h Handler) UpdateAuthor(c *gin.Context) {
 // @Tags        authors
 // @Accept      json
 // @Param       id            path   string true  "author ID"
+// @Param       If-Match      header string false "ETag the deletion is based on"
 // @Param       Authorization header string false "Authorization"
 // @Produce     json
 // @Success     200 {object} models.JSONResponse{data=models.Author}
-// @Failure     404 {object} models.JSONErrorResponse
+// @Failure     404 {object} models.Problem
+// @Failure     412 {object} models.Problem
+// @Failure     422 {object} models.Problem
 // @Router      /v1/author/{id} [delete]
 func (h Handler) DeleteAuthor(c *gin.Context) {
-	idStr := c.Param("id")
-	deleted, err := h.grpcClients.Author.DeleteAuthor(c.Request.Context(), &author.Id{
+	idStr, ok := pathID(c)
+	if !ok {
+		return
+	}
+
+	// DeleteAuthor replies with an empty message, so the author is read before it is gone
+	deleted, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
 		Id: idStr,
 	})
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.JSONErrorResponse{
-			Error: "Author have been deleted already!"})
+		response.GRPCError(c, err)
+		return
+	}
+	if !ifMatch(c, authorETag(deleted)) {
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
-		"message": "author deleted",
-		"data":    deleted,
+
+	_, err = h.grpcClients.Author.DeleteAuthor(c.Request.Context(), &author.Id{
+		Id: idStr,
 	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	a := models.NewAuthorFromRes(deleted)
+	h.publish(events.AuthorDeleted, a.ID, c.GetString("auth_user_id"), a)
+
+	response.OK(c, http.StatusOK, "author deleted", a)
 }
@@ -3,7 +3,7 @@ package handlers
 import (
 	"blogpost/genprotos/authorization"
 	"blogpost/models"
-	"log"
+	"blogpost/response"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -17,28 +17,25 @@ func (h Handler) AuthMiddleware(userType string) gin.HandlerFunc {
 			Token: token,
 		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-				Error: err.Error(),
-			})
-			c.Abort()
+			response.GRPCError(c, err)
 			return
 		}
 
 		if !hasAccessResponse.HasAccess {
-			c.JSON(http.StatusUnauthorized, "Unauthorized")
-			c.Abort()
+			response.Error(c, http.StatusUnauthorized, "invalid or missing token")
 			return
 		}
 
 		if userType != "*" {
 			if hasAccessResponse.User.UserType != userType {
-				c.JSON(http.StatusUnauthorized, "Permission Denied")
-				c.Abort()
+				response.Error(c, http.StatusForbidden, "Permission Denied")
+				return
 			}
 		}
 
 		c.Set("auth_username", hasAccessResponse.User.Username)
 		c.Set("auth_user_id", hasAccessResponse.User.Id)
+		c.Set("auth_user", hasAccessResponse.User)
 
 		c.Next()
 		//
@@ -53,29 +50,24 @@ func (h Handler) AuthMiddleware(userType string) gin.HandlerFunc {
 // @Produce     json
 // @Param       login body     models.LoginModel true "Login body"
 // @Success     201   {object} models.JSONResponse{data=models.TokenResponse}
-// @Failure     400   {object} models.JSONErrorResponse
+// @Failure     400   {object} models.Problem
+// @Failure     401   {object} models.Problem
+// @Failure     422   {object} models.Problem
 // @Router      /v1/login [post]
 func (h Handler) Login(c *gin.Context) {
 	var body models.LoginModel
-	if err := c.ShouldBindJSON(&body); err != nil {
-		c.JSON(http.StatusBadRequest, models.JSONErrorResponse{Error: "err.Error()1"})
+	if !bindBody(c, &body) {
 		return
 	}
-	log.Println("-------------------------->", body.Password, body.Username)
-	// TODO - validation should be here
+
 	tokenResponse, err := h.grpcClients.Authorization.Login(c.Request.Context(), &authorization.LoginRequest{
 		Username: body.Username,
 		Password: body.Password,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.JSONErrorResponse{
-			Error: "err.Error()2",
-		})
+		response.GRPCError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, models.JSONResponse{
-		Message: "Article | GetList",
-		Data:    tokenResponse,
-	})
+	response.OK(c, http.StatusCreated, "Login | OK", models.NewTokenResponse(tokenResponse))
 }
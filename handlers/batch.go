@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// batchHeaders are the sub-response headers copied into a batch result
+var batchHeaders = []string{"ETag", "Location", "Deprecation", "Sunset"}
+
+// compensable are the routes an atomic batch may POST to, their response names the id of the created
+// resource and the route takes a DELETE of it. Others create nothing that can be found again or undone
+var compensable = map[string]bool{
+	"/v1/article": true,
+}
+
+// compensateTimeout bounds the deletes undoing an atomic batch, they run even if the caller went away
+const compensateTimeout = 30 * time.Second
+
+// Batch godoc
+// @Summary     Batch operations
+// @Description run several requests in one, each is routed through the gateway with the caller's Authorization.
+// @Description Streaming routes such as events and exports cannot be batched.
+// @Description With atomic set the operations run one by one, only GET and POST to /v1/article are allowed and the created
+// @Description articles are deleted again when any operation fails, compensation tells the outcome for each of them
+// @Tags        batch
+// @Accept      json
+// @Produce     json
+// @Param       batch         body     models.BatchRequest true  "operations"
+// @Param       Authorization header   string              false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=[]models.BatchResult}
+// @Success     207           {object} models.JSONResponse{data=[]models.BatchResult} "some operations failed"
+// @Failure     400           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/batch [post]
+func (h Handler) Batch(c *gin.Context) {
+	var body models.BatchRequest
+	if !bindBody(c, &body) {
+		return
+	}
+	if errs := h.checkBatch(body); len(errs) > 0 {
+		response.ValidationError(c, "the batch cannot be run", errs)
+		return
+	}
+	if h.engine.Handler == nil {
+		response.Error(c, http.StatusInternalServerError, "batch requests are not available")
+		return
+	}
+
+	results := make([]models.BatchResult, len(body.Operations))
+	var (
+		mu     sync.Mutex
+		failed bool
+	)
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < h.batchWorkers(len(body.Operations), body.Atomic); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				op := body.Operations[index]
+
+				mu.Lock()
+				skip := body.Atomic && failed
+				mu.Unlock()
+				if skip {
+					results[index] = models.BatchResult{ID: op.ID, Status: http.StatusFailedDependency}
+					continue
+				}
+
+				payload, _ := operationBody(op)
+				results[index] = h.runOperation(c.Request.Context(), c, index, op.Method, op.Path, op.ContentType, payload)
+				results[index].ID = op.ID
+				if results[index].Status >= http.StatusBadRequest {
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range body.Operations {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	message := "Batch | OK"
+	code := http.StatusOK
+	if failed {
+		message = "Batch | Partially failed"
+		code = http.StatusMultiStatus
+	}
+	if failed && body.Atomic {
+		message = "Batch | Rolled back"
+		if !h.compensate(c, body.Operations, results) {
+			message = "Batch | Rollback incomplete"
+		}
+	}
+
+	response.OK(c, code, message, results)
+}
+
+// checkBatch lists what keeps a batch from running
+func (h Handler) checkBatch(body models.BatchRequest) []models.FieldError {
+	var errs []models.FieldError
+	if max := h.Conf.BatchMaxOperations; max > 0 && len(body.Operations) > max {
+		errs = append(errs, models.FieldError{
+			Field:   "operations",
+			Message: fmt.Sprintf("must contain at most %d operations", max),
+		})
+	}
+
+	for i, op := range body.Operations {
+		field := fmt.Sprintf("operations[%d]", i)
+		target, err := url.Parse(op.Path)
+		if err != nil || target.IsAbs() || target.Host != "" {
+			errs = append(errs, models.FieldError{Field: field + ".path", Message: "must be a path of this gateway"})
+		} else if route := path.Clean(target.Path); route == "/v1/batch" {
+			errs = append(errs, models.FieldError{Field: field + ".path", Message: "batches cannot be nested"})
+		} else if streaming(route) {
+			errs = append(errs, models.FieldError{Field: field + ".path", Message: "streams cannot be batched"})
+		} else if body.Atomic && op.Method == http.MethodPost && !compensable[route] {
+			errs = append(errs, models.FieldError{Field: field + ".path", Message: "cannot be undone in an atomic batch"})
+		}
+		if body.Atomic && op.Method != http.MethodGet && op.Method != http.MethodPost {
+			errs = append(errs, models.FieldError{Field: field + ".method", Message: "must be GET or POST in an atomic batch"})
+		}
+		if _, err := operationBody(op); err != nil {
+			errs = append(errs, models.FieldError{Field: field + ".body", Message: err.Error()})
+		}
+	}
+	return errs
+}
+
+// streaming reports whether route answers with a stream that would hold a batch up or fill its memory
+func streaming(route string) bool {
+	return route == "/v1/events" || route == "/graphql" || strings.HasSuffix(route, "/export")
+}
+
+// operationBody is the body an operation is sent with. A JSON body is passed on as it is, any other
+// content type cannot be embedded in the batch as it is, so its body comes as a JSON string
+func operationBody(op models.BatchOperation) ([]byte, error) {
+	if len(op.Body) == 0 || op.ContentType == "" || isJSONDocument(op.ContentType) {
+		return op.Body, nil
+	}
+	var text string
+	if err := json.Unmarshal(op.Body, &text); err != nil {
+		return nil, errors.New("must be a string when content_type is not JSON")
+	}
+	return []byte(text), nil
+}
+
+// batchWorkers is the number of operations run at once, an atomic batch runs them in order so nothing
+// starts after the first failure
+func (h Handler) batchWorkers(operations int, atomic bool) int {
+	workers := h.Conf.BatchConcurrency
+	if workers <= 0 || atomic {
+		workers = 1
+	}
+	if workers > operations {
+		workers = operations
+	}
+	return workers
+}
+
+// runOperation routes one sub-request through the gateway on behalf of the batch caller
+func (h Handler) runOperation(ctx context.Context, c *gin.Context, index int, method, path, contentType string, body []byte) models.BatchResult {
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(body))
+	if err != nil {
+		return batchProblem(http.StatusBadRequest, err.Error())
+	}
+	req.RemoteAddr = c.Request.RemoteAddr
+	req.Header.Set("Authorization", c.GetHeader("Authorization"))
+	req.Header.Set(RequestIDHeader, fmt.Sprintf("%s-%d", c.GetString(response.RequestIDKey), index+1))
+	if len(body) > 0 {
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	recorder := httptest.NewRecorder()
+	h.engine.ServeHTTP(recorder, req)
+
+	result := models.BatchResult{Status: recorder.Code}
+	for _, name := range batchHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			if result.Headers == nil {
+				result.Headers = make(map[string]string)
+			}
+			result.Headers[name] = value
+		}
+	}
+	switch raw := recorder.Body.Bytes(); {
+	case len(raw) == 0:
+	case json.Valid(raw):
+		result.Body = raw
+	default:
+		result.Body, _ = json.Marshal(string(raw))
+	}
+	return result
+}
+
+// compensate deletes what the successful POST operations of a failed atomic batch created and records
+// the outcome in their results, it reports whether everything was undone
+func (h Handler) compensate(c *gin.Context, ops []models.BatchOperation, results []models.BatchResult) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), compensateTimeout)
+	defer cancel()
+
+	complete := true
+	for i, op := range ops {
+		if op.Method != http.MethodPost || results[i].Status >= http.StatusMultipleChoices {
+			continue
+		}
+		id := createdID(results[i].Body)
+		if id == "" {
+			results[i].Compensation = models.BatchSkipped
+			complete = false
+			continue
+		}
+
+		target := strings.TrimSuffix(strings.SplitN(op.Path, "?", 2)[0], "/") + "/" + url.PathEscape(id)
+		undo := h.runOperation(ctx, c, len(ops)+i, http.MethodDelete, target, "", nil)
+		if undo.Status >= http.StatusMultipleChoices {
+			results[i].Compensation = models.BatchFailed
+			complete = false
+			continue
+		}
+		results[i].Compensation = models.BatchRolledBack
+	}
+	return complete
+}
+
+// createdID reads data.id from a success envelope
+func createdID(body json.RawMessage) string {
+	var envelope struct {
+		Data struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return ""
+	}
+	return envelope.Data.ID
+}
+
+func batchProblem(code int, detail string) models.BatchResult {
+	body, _ := json.Marshal(models.Problem{
+		Type:   response.TypeDefault,
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: detail,
+	})
+	return models.BatchResult{Status: code, Body: body}
+}
@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"blogpost/config"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOperationBody(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+		wantErr     bool
+	}{
+		{name: "default is JSON", body: `{"title":"a"}`, want: `{"title":"a"}`},
+		{name: "JSON", contentType: "application/json", body: `{"title":"a"}`, want: `{"title":"a"}`},
+		{name: "JSON string stays JSON", contentType: "application/merge-patch+json", body: `"x"`, want: `"x"`},
+		{name: "XML string", contentType: "application/xml", body: `"<article title=\"a\"/>"`, want: `<article title="a"/>`},
+		{name: "CSV string", contentType: "text/csv", body: `"title,body\na,\"b, c\"\n"`, want: "title,body\na,\"b, c\"\n"},
+		{name: "no body", contentType: "text/csv"},
+		{name: "CSV object", contentType: "text/csv", body: `{"title":"a"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			op := models.BatchOperation{ContentType: tt.contentType}
+			if tt.body != "" {
+				op.Body = json.RawMessage(tt.body)
+			}
+			got, err := operationBody(op)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("operationBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if string(got) != tt.want {
+				t.Errorf("operationBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckBatch(t *testing.T) {
+	h := Handler{Conf: config.Config{BatchMaxOperations: 10}}
+	tests := []struct {
+		name   string
+		atomic bool
+		method string
+		path   string
+		want   string
+	}{
+		{name: "read", method: http.MethodGet, path: "/v1/article/1"},
+		{name: "create", method: http.MethodPost, path: "/v1/author"},
+		{name: "atomic article create", atomic: true, method: http.MethodPost, path: "/v1/article"},
+		{name: "atomic author create", atomic: true, method: http.MethodPost, path: "/v1/author", want: "operations[0].path"},
+		{name: "atomic import", atomic: true, method: http.MethodPost, path: "/v1/article/import?report=csv", want: "operations[0].path"},
+		{name: "atomic delete", atomic: true, method: http.MethodDelete, path: "/v1/article/1", want: "operations[0].method"},
+		{name: "events", method: http.MethodGet, path: "/v1/events", want: "operations[0].path"},
+		{name: "export", method: http.MethodGet, path: "/v1/article/export?format=csv", want: "operations[0].path"},
+		{name: "export behind dot segments", method: http.MethodGet, path: "/v1/author/./export", want: "operations[0].path"},
+		{name: "graphql", method: http.MethodPost, path: "/v1/../graphql", want: "operations[0].path"},
+		{name: "nested batch", method: http.MethodPost, path: "/v1/batch/", want: "operations[0].path"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := h.checkBatch(models.BatchRequest{
+				Atomic:     tt.atomic,
+				Operations: []models.BatchOperation{{Method: tt.method, Path: tt.path}},
+			})
+			switch {
+			case tt.want == "" && len(errs) > 0:
+				t.Errorf("checkBatch() = %v, want no errors", errs)
+			case tt.want != "" && (len(errs) != 1 || errs[0].Field != tt.want):
+				t.Errorf("checkBatch() = %v, want an error on %s", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestAtomicBatchReportsCompensation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := Handler{Conf: config.Config{BatchConcurrency: 4}, engine: &engineRef{}}
+	var (
+		mu      sync.Mutex
+		created []string
+		deleted []string
+	)
+	engine := gin.New()
+	engine.POST("/v1/article", func(c *gin.Context) {
+		var body struct {
+			ID   string `json:"id"`
+			Fail bool   `json:"fail"`
+		}
+		c.ShouldBindJSON(&body)
+		mu.Lock()
+		created = append(created, body.ID)
+		mu.Unlock()
+		if body.Fail {
+			response.Error(c, http.StatusBadRequest, "invalid article")
+			return
+		}
+		if body.ID == "" {
+			response.OK(c, http.StatusCreated, "Created", nil)
+			return
+		}
+		response.OK(c, http.StatusCreated, "Created", gin.H{"id": body.ID})
+	})
+	engine.DELETE("/v1/article/:id", func(c *gin.Context) {
+		if c.Param("id") == "stuck" {
+			response.Error(c, http.StatusInternalServerError, "delete failed")
+			return
+		}
+		deleted = append(deleted, c.Param("id"))
+		c.Status(http.StatusNoContent)
+	})
+	engine.POST("/v1/batch", h.Batch)
+	h.SetEngine(engine)
+
+	ops := []string{`{"id":"a"}`, `{}`, `{"id":"stuck"}`, `{"id":"bad","fail":true}`, `{"id":"late"}`}
+	var batch models.BatchRequest
+	batch.Atomic = true
+	for _, body := range ops {
+		batch.Operations = append(batch.Operations, models.BatchOperation{Method: http.MethodPost, Path: "/v1/article", Body: json.RawMessage(body)})
+	}
+	raw, _ := json.Marshal(batch)
+	w := serve(t, engine, http.MethodPost, "/v1/batch", string(raw))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status = %d, want 207: %s", w.Code, w.Body.String())
+	}
+
+	var got struct {
+		Message string               `json:"message"`
+		Data    []models.BatchResult `json:"data"`
+	}
+	decodeBody(t, w, &got)
+	if got.Message != "Batch | Rollback incomplete" {
+		t.Errorf("message = %q, want Batch | Rollback incomplete", got.Message)
+	}
+	want := []struct {
+		status       int
+		compensation string
+	}{
+		{http.StatusCreated, models.BatchRolledBack},
+		{http.StatusCreated, models.BatchSkipped},
+		{http.StatusCreated, models.BatchFailed},
+		{http.StatusBadRequest, ""},
+		{http.StatusFailedDependency, ""},
+	}
+	if len(got.Data) != len(want) {
+		t.Fatalf("got %d results, want %d", len(got.Data), len(want))
+	}
+	for i, w := range want {
+		if got.Data[i].Status != w.status || got.Data[i].Compensation != w.compensation {
+			t.Errorf("result %d = %d %q, want %d %q", i, got.Data[i].Status, got.Data[i].Compensation, w.status, w.compensation)
+		}
+	}
+	if strings.Join(created, ",") != "a,,stuck,bad" {
+		t.Errorf("created %q, want the operations in order up to the failure", created)
+	}
+	if strings.Join(deleted, ",") != "a" {
+		t.Errorf("deleted %q, want a", deleted)
+	}
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpost/cache"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Cache namespaces, a write through the gateway bumps the namespace of the resource it touched
+const (
+	CacheArticles = "article"
+	CacheAuthors  = "author"
+)
+
+// Warning header values (RFC 7234) for stale responses
+const (
+	warningStale            = `110 - "Response is Stale"`
+	warningRevalidateFailed = `111 - "Revalidation Failed"`
+)
+
+// cachedHeaders are the response headers kept together with a cached body
+var cachedHeaders = []string{"Content-Type", "ETag", "Last-Modified"}
+
+type revalidateKey struct{}
+
+// holdWriter keeps the response back until the cache middleware decides between it and a stale copy
+type holdWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *holdWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *holdWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// Cached serves GET responses from the cache for ttl, the key is made of the path, the query, the caller's
+// role and the current version of every namespace in resources, so a write to any of them invalidates the entry.
+// Past ttl an entry is served stale while it is refreshed in the background, and once that window is over
+// it is still served when the backend fails
+func (h Handler) Cached(ttl time.Duration, resources ...string) gin.HandlerFunc {
+	return h.cached(ttl, true, resources)
+}
+
+// CachedByPath is Cached for routes that read no query params, the query is left out of the key
+// so requests cannot add entries by varying it
+func (h Handler) CachedByPath(ttl time.Duration, resources ...string) gin.HandlerFunc {
+	return h.cached(ttl, false, resources)
+}
+
+func (h Handler) cached(ttl time.Duration, byQuery bool, resources []string) gin.HandlerFunc {
+	staleWhileRevalidate := h.Conf.CacheStaleWhileRevalidate
+	staleIfError := h.Conf.CacheStaleIfError
+	keep := ttl + staleWhileRevalidate
+	if ttl+staleIfError > keep {
+		keep = ttl + staleIfError
+	}
+
+	return func(c *gin.Context) {
+		// entries hold JSON documents, the generated message a protobuf response needs is not kept
+		if h.cache == nil || ttl <= 0 || c.Request.Method != http.MethodGet || prefersProtobuf(c) {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, err := h.cacheKey(c, byQuery, resources)
+		if err != nil {
+			// the store is unreachable, serve without it
+			c.Next()
+			return
+		}
+
+		var stale *cache.Entry
+		if ctx.Value(revalidateKey{}) == nil {
+			if raw, err := h.cache.Get(ctx, key); err == nil {
+				if entry, err := cache.DecodeEntry(raw); err == nil {
+					age := entry.Age(time.Now())
+					switch {
+					case age < ttl:
+						serveCached(c, entry, "HIT", "", ttl)
+						return
+					case age < ttl+staleWhileRevalidate:
+						h.revalidate(c, key)
+						serveCached(c, entry, "STALE", warningStale, ttl)
+						return
+					case age < ttl+staleIfError:
+						stale = &entry
+					}
+				}
+			}
+		}
+
+		w := &holdWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if stale != nil && w.Status() >= http.StatusInternalServerError {
+			serveCached(c, *stale, "STALE", warningRevalidateFailed, ttl)
+			return
+		}
+
+		if w.Status() == http.StatusOK && w.body.Len() > 0 {
+			setCacheHeaders(w.Header(), cacheScope(c), "MISS", "", ttl, 0)
+			h.storeEntry(ctx, key, w, keep)
+		}
+		if w.body.Len() > 0 {
+			w.ResponseWriter.Write(w.body.Bytes())
+		}
+	}
+}
+
+// Invalidates bumps the cache namespaces of resources once a write succeeded
+func (h Handler) Invalidates(resources ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		h.invalidate(c.Request.Context(), resources...)
+	}
+}
+
+// invalidate bumps the cache namespaces of resources
+func (h Handler) invalidate(ctx context.Context, resources ...string) {
+	if h.cache == nil {
+		return
+	}
+	for _, resource := range resources {
+		h.cache.Bump(ctx, resource)
+	}
+}
+
+func (h Handler) cacheKey(c *gin.Context, byQuery bool, resources []string) (string, error) {
+	role := "anonymous"
+	if user, ok := authUser(c); ok {
+		role = user.UserType
+	}
+
+	var key strings.Builder
+	key.WriteString(c.Request.URL.Path)
+	if byQuery {
+		key.WriteString("?")
+		key.WriteString(c.Request.URL.Query().Encode())
+	}
+	key.WriteString("|role=")
+	key.WriteString(role)
+	for _, resource := range resources {
+		version, err := h.cache.Version(c.Request.Context(), resource)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&key, "|%s=%d", resource, version)
+	}
+	return key.String(), nil
+}
+
+func (h Handler) storeEntry(ctx context.Context, key string, w *holdWriter, keep time.Duration) {
+	entry := cache.Entry{
+		Status:   w.Status(),
+		Header:   make(map[string]string, len(cachedHeaders)),
+		Body:     append([]byte(nil), w.body.Bytes()...),
+		StoredAt: time.Now(),
+	}
+	for _, name := range cachedHeaders {
+		if value := w.Header().Get(name); value != "" {
+			entry.Header[name] = value
+		}
+	}
+	if raw, err := entry.Encode(); err == nil {
+		h.cache.Set(ctx, key, raw, keep)
+	}
+}
+
+// revalidate replays the request through the gateway in the background so the entry under key is
+// stored again, at most one refresh per key runs at a time
+func (h Handler) revalidate(c *gin.Context, key string) {
+	if h.engine.Handler == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), revalidateKey{}, true), h.Conf.GrpcTimeout+time.Second)
+	req := c.Request.Clone(ctx)
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Match")
+
+	go func() {
+		defer cancel()
+		h.refreshing.Do(key, func() (interface{}, error) {
+			h.engine.ServeHTTP(httptest.NewRecorder(), req)
+			return nil, nil
+		})
+	}()
+}
+
+func serveCached(c *gin.Context, entry cache.Entry, state, warning string, ttl time.Duration) {
+	for name, value := range entry.Header {
+		c.Header(name, value)
+	}
+	setCacheHeaders(c.Writer.Header(), cacheScope(c), state, warning, ttl, entry.Age(time.Now()))
+
+	modified, _ := http.ParseTime(entry.Header["Last-Modified"])
+	if etag := entry.Header["ETag"]; (etag != "" || !modified.IsZero()) && notModifiedSince(c, etag, modified) {
+		return
+	}
+
+	c.Data(entry.Status, entry.Header["Content-Type"], entry.Body)
+	c.Abort()
+}
+
+// cacheScope lets shared caches keep responses to anonymous requests such as feeds, everything else is private
+func cacheScope(c *gin.Context) string {
+	if _, ok := authUser(c); ok {
+		return "private"
+	}
+	return "public"
+}
+
+// setCacheHeaders describes the freshness of a response
+func setCacheHeaders(header http.Header, scope, state, warning string, ttl, age time.Duration) {
+	maxAge := int((ttl - age).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	header.Set("Cache-Control", scope+", max-age="+strconv.Itoa(maxAge))
+	header.Set("Age", strconv.Itoa(int(age.Seconds())))
+	header.Set("X-Cache", state)
+	if warning != "" {
+		header.Set("Warning", warning)
+	}
+}
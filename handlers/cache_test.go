@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"blogpost/cache"
+	"blogpost/clients"
+	"blogpost/config"
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storedAuthorClient holds testUser's author, counts reads and can be made to fail
+type storedAuthorClient struct {
+	fakeAuthorClient
+
+	mu       sync.Mutex
+	fullname string
+	updated  string
+	reads    int
+	down     bool
+}
+
+func (f *storedAuthorClient) GetAuthorByID(ctx context.Context, in *author.Id, opts ...grpc.CallOption) (*author.GetAuthorByIdRes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.reads++
+	if f.down {
+		return nil, status.Error(codes.Unavailable, "author service is down")
+	}
+	if in.GetId() != testUser.Id {
+		return nil, status.Error(codes.NotFound, "author not found")
+	}
+	return &author.GetAuthorByIdRes{Id: testUser.Id, Fullname: f.fullname, CreatedAt: testUser.CreatedAt, UpdatedAt: f.updated}, nil
+}
+
+func (f *storedAuthorClient) UpdateAuthor(ctx context.Context, in *author.UpdateAuthorReq, opts ...grpc.CallOption) (*author.CreateAuthorRes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fullname = in.GetFullname()
+	f.updated = "2026-02-03T04:05:06Z"
+	return &author.CreateAuthorRes{}, nil
+}
+
+func (f *storedAuthorClient) readCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads
+}
+
+func (f *storedAuthorClient) set(fullname string, down bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fullname = fullname
+	f.down = down
+}
+
+// newCacheRouter mounts the author routes behind the cache like main does
+func newCacheRouter(t *testing.T, conf config.Config, ttl time.Duration) (*gin.Engine, *storedAuthorClient, cache.Store) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	authors := &storedAuthorClient{fullname: "Alice"}
+	store := cache.NewMemoryStore(100)
+	h, err := NewHandler(conf, &clients.GrpcClients{Authorization: fakeAuthClient{}, Author: authors}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	v1 := router.Group("/v1")
+	v1.Use(Negotiate(), SanitizeResponse())
+	v1.GET("/author/:id", h.AuthMiddleware("*"), h.Cached(ttl, CacheAuthors, CacheArticles), h.GetAuthorByID)
+	v1.PUT("/author/:id", h.AuthMiddleware("*"), NoCoalescing(), h.Invalidates(CacheAuthors), h.UpdateAuthorByID)
+	h.SetEngine(router)
+	return router, authors, store
+}
+
+func getCached(t *testing.T, router http.Handler, target string, header map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Set("Authorization", testToken)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCachedServesHitsAndNotModified(t *testing.T) {
+	router, authors, _ := newCacheRouter(t, config.Config{}, time.Minute)
+	target := "/v1/author/" + testUser.Id
+
+	first := getCached(t, router, target, nil)
+	if first.Code != http.StatusOK || first.Header().Get("X-Cache") != "MISS" {
+		t.Fatalf("first read: status %d X-Cache %q, want 200 MISS", first.Code, first.Header().Get("X-Cache"))
+	}
+	etag := first.Header().Get("ETag")
+
+	second := getCached(t, router, target, nil)
+	if second.Code != http.StatusOK || second.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("second read: status %d X-Cache %q, want 200 HIT", second.Code, second.Header().Get("X-Cache"))
+	}
+	if second.Body.String() != first.Body.String() || second.Header().Get("ETag") != etag {
+		t.Errorf("hit differs from the stored response: %s", second.Body.String())
+	}
+	if !strings.HasPrefix(second.Header().Get("Cache-Control"), "private") {
+		t.Errorf("Cache-Control = %q, want a private response for an authenticated caller", second.Header().Get("Cache-Control"))
+	}
+
+	conditional := getCached(t, router, target, map[string]string{"If-None-Match": etag})
+	if conditional.Code != http.StatusNotModified || conditional.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("conditional read: status %d X-Cache %q, want 304 HIT", conditional.Code, conditional.Header().Get("X-Cache"))
+	}
+	if conditional.Body.Len() != 0 {
+		t.Errorf("304 has a body: %s", conditional.Body.String())
+	}
+
+	if reads := authors.readCount(); reads != 1 {
+		t.Errorf("the author service was read %d times, want 1", reads)
+	}
+}
+
+func TestCachedInvalidatedByWrites(t *testing.T) {
+	router, authors, _ := newCacheRouter(t, config.Config{}, time.Minute)
+	target := "/v1/author/" + testUser.Id
+
+	getCached(t, router, target, nil)
+	if w := serve(t, router, http.MethodPut, target, `{"fullname":"Alice Liddell"}`); w.Code != http.StatusOK {
+		t.Fatalf("update: status %d: %s", w.Code, w.Body.String())
+	}
+	reads := authors.readCount()
+
+	w := getCached(t, router, target, nil)
+	if w.Header().Get("X-Cache") != "MISS" || !strings.Contains(w.Body.String(), "Alice Liddell") {
+		t.Errorf("read after the update: X-Cache %q body %s, want a fresh MISS", w.Header().Get("X-Cache"), w.Body.String())
+	}
+	if authors.readCount() != reads+1 {
+		t.Errorf("the read after the update did not reach the author service")
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewMemoryStore(100)
+	h, err := NewHandler(config.Config{}, nil, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources := []string{CacheAuthors, CacheArticles}
+	key := func(target, role string) string {
+		t.Helper()
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		if role != "" {
+			c.Set("auth_user", &authorization.User{Id: "1", UserType: role})
+		}
+		key, err := h.cacheKey(c, true, resources)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	base := key("/v1/author?limit=5&offset=10", "user")
+	if other := key("/v1/author?offset=10&limit=5", "user"); other != base {
+		t.Errorf("the order of query params changes the key: %s and %s", base, other)
+	}
+	for _, tt := range []struct{ target, role string }{
+		{"/v1/author?limit=5&offset=10", "admin"},
+		{"/v1/author?limit=5&offset=10", ""},
+		{"/v1/author?limit=5&offset=20", "user"},
+		{"/v1/article?limit=5&offset=10", "user"},
+	} {
+		if other := key(tt.target, tt.role); other == base {
+			t.Errorf("%s as %q shares the key %s", tt.target, tt.role, base)
+		}
+	}
+
+	store.Bump(context.Background(), "comment")
+	if other := key("/v1/author?limit=5&offset=10", "user"); other != base {
+		t.Errorf("bumping an unrelated namespace changed the key")
+	}
+	store.Bump(context.Background(), CacheArticles)
+	if other := key("/v1/author?limit=5&offset=10", "user"); other == base {
+		t.Errorf("bumping %s kept the key %s", CacheArticles, base)
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/feeds/articles.rss?junk=1", nil)
+	byPath, _ := h.cacheKey(c, false, resources)
+	if strings.Contains(byPath, "junk") {
+		t.Errorf("a key by path holds the query: %s", byPath)
+	}
+}
+
+func TestCachedServesStaleWhileRevalidating(t *testing.T) {
+	ttl := 200 * time.Millisecond
+	router, authors, _ := newCacheRouter(t, config.Config{CacheStaleWhileRevalidate: time.Minute}, ttl)
+	target := "/v1/author/" + testUser.Id
+
+	getCached(t, router, target, nil)
+	// changed behind the gateway's back, only a refresh can see it
+	authors.set("Bob", false)
+	time.Sleep(ttl + 50*time.Millisecond)
+
+	stale := getCached(t, router, target, nil)
+	if stale.Code != http.StatusOK || stale.Header().Get("X-Cache") != "STALE" || stale.Header().Get("Warning") != warningStale {
+		t.Fatalf("read past ttl: status %d X-Cache %q Warning %q, want 200 STALE with warning 110",
+			stale.Code, stale.Header().Get("X-Cache"), stale.Header().Get("Warning"))
+	}
+	if !strings.Contains(stale.Body.String(), "Alice") {
+		t.Errorf("stale read did not serve the stored response: %s", stale.Body.String())
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		w := getCached(t, router, target, nil)
+		if strings.Contains(w.Body.String(), "Bob") {
+			if w.Header().Get("X-Cache") != "HIT" {
+				t.Errorf("refreshed entry served with X-Cache %q, want HIT", w.Header().Get("X-Cache"))
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the entry was not refreshed in the background, last read: %s", w.Body.String())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCachedServesStaleIfError(t *testing.T) {
+	ttl := 50 * time.Millisecond
+	router, authors, _ := newCacheRouter(t, config.Config{CacheStaleIfError: time.Minute}, ttl)
+	target := "/v1/author/" + testUser.Id
+
+	getCached(t, router, target, nil)
+	authors.set("Bob", true)
+	time.Sleep(ttl + 20*time.Millisecond)
+
+	w := getCached(t, router, target, nil)
+	if w.Code != http.StatusOK || w.Header().Get("X-Cache") != "STALE" || w.Header().Get("Warning") != warningRevalidateFailed {
+		t.Fatalf("read with the backend down: status %d X-Cache %q Warning %q, want 200 STALE with warning 111",
+			w.Code, w.Header().Get("X-Cache"), w.Header().Get("Warning"))
+	}
+	if !strings.Contains(w.Body.String(), "Alice") {
+		t.Errorf("stale read did not serve the stored response: %s", w.Body.String())
+	}
+	if reads := authors.readCount(); reads != 2 {
+		t.Errorf("the author service was read %d times, want the failed read to reach it", reads)
+	}
+
+	authors.set("Bob", false)
+	if w := getCached(t, router, target, nil); w.Header().Get("X-Cache") != "MISS" || !strings.Contains(w.Body.String(), "Bob") {
+		t.Errorf("read after recovery: X-Cache %q body %s, want a fresh MISS", w.Header().Get("X-Cache"), w.Body.String())
+	}
+}
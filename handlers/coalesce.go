@@ -0,0 +1,16 @@
+package handlers
+
+import (
+	"blogpost/clients"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NoCoalescing opts a route out of sharing gRPC reads with identical concurrent requests,
+// write routes use it so the reads behind If-Match and read-backs see the latest state
+func NoCoalescing() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(clients.WithoutCoalescing(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,359 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"blogpost/metrics"
+	"blogpost/response"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings the gateway reads and writes
+const (
+	encodingGzip   = "gzip"
+	encodingBrotli = "br"
+	encodingZstd   = "zstd"
+)
+
+// encoder is the part gzip, brotli and zstd writers have in common
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders keeps the writers of each encoding for reuse, their windows are most of a response's allocations.
+// A pool that cannot build a writer gives nil and the response goes out as it is
+var encoders = map[string]*sync.Pool{
+	encodingGzip: {New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	}},
+	encodingBrotli: {New: func() interface{} {
+		return brotli.NewWriterLevel(io.Discard, 5)
+	}},
+	encodingZstd: {New: func() interface{} {
+		w, err := zstd.NewWriter(io.Discard, zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			log.Println("zstd writer:", err)
+			return nil
+		}
+		return w
+	}},
+}
+
+// Compress encodes responses in the encoding Accept-Encoding prefers among conf.CompressEncodings.
+// A body is held back until it reaches conf.CompressMinSize so small ones go out as they are, a handler
+// that flushes earlier is streaming and is compressed whatever its size. Content types with a prefix in
+// conf.CompressExcludedTypes are already compressed or must not be held back and are left alone.
+// A compressed response gets the encoding as a suffix of its ETag, so a cache never takes it for the
+// identity bytes, and the suffix is stripped from the request's conditionals before the handler sees them
+func (h Handler) Compress() gin.HandlerFunc {
+	var offered []string
+	for _, encoding := range splitList(h.Conf.CompressEncodings) {
+		if _, ok := encoders[encoding]; ok {
+			offered = append(offered, encoding)
+		}
+	}
+	excluded := splitList(h.Conf.CompressExcludedTypes)
+
+	return func(c *gin.Context) {
+		if len(offered) == 0 {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"), offered)
+		ifNoneMatch := c.GetHeader("If-None-Match")
+		unwrapConditionals(c.Request, encoding, true, isEncoding)
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{
+			ResponseWriter: c.Writer,
+			encoding:       encoding,
+			minSize:        h.Conf.CompressMinSize,
+			excluded:       excluded,
+			ifNoneMatch:    ifNoneMatch,
+		}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.finish()
+	}
+}
+
+// isEncoding tells the ETag suffixes Compress adds
+func isEncoding(suffix string) bool {
+	_, ok := encoders[suffix]
+	return ok
+}
+
+// negotiateEncoding picks the encoding of offered with the highest quality in an Accept-Encoding header,
+// ties go to the gateway's preference. It is empty when the response is better sent as it is
+func negotiateEncoding(header string, offered []string) string {
+	if strings.TrimSpace(header) == "" {
+		return ""
+	}
+	qualities := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				parsed, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					parsed = 0
+				}
+				q = parsed
+			}
+		}
+		if coding == "x-gzip" {
+			coding = encodingGzip
+		}
+		qualities[coding] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, encoding := range offered {
+		q, ok := qualities[encoding]
+		if !ok {
+			q = qualities["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// compressWriter holds a body back until it is large enough to be worth compressing, then writes
+// it and everything after through the encoder, or as it is when the response is not to be compressed
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	excluded []string
+	// ifNoneMatch is the header as the client sent it, a 304 answers with the tag it matched
+	ifNoneMatch string
+
+	body        bytes.Buffer
+	decided     bool
+	wroteHeader bool
+	encoder     encoder
+	in          int64
+	out         countingWriter
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.decided {
+		w.body.Write(b)
+		if w.body.Len() >= w.minSize {
+			if err := w.decide(false); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.encoder == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	w.in += int64(len(b))
+	return w.encoder.Write(b)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow is postponed until it is known whether the body is compressed
+func (w *compressWriter) WriteHeaderNow() {
+	if w.decided {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.wroteHeader = true
+}
+
+func (w *compressWriter) Written() bool {
+	return w.wroteHeader || w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush sends what the handler wrote so far, a streamed response is compressed even when it is small
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(false); err != nil {
+			return
+		}
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide settles the encoding of the response and writes what was held back, final is set once the
+// handler is done so the size threshold applies
+func (w *compressWriter) decide(final bool) error {
+	w.decided = true
+	if w.compressible(final) {
+		if enc, ok := encoders[w.encoding].Get().(encoder); ok {
+			header := w.Header()
+			header.Set("Content-Encoding", w.encoding)
+			header.Del("Content-Length")
+			if etag := header.Get("ETag"); etag != "" {
+				header.Set("ETag", variantETag(etag, w.encoding))
+			}
+			w.out.w = w.ResponseWriter
+			w.encoder = enc
+			w.encoder.Reset(&w.out)
+		}
+	} else if etag := w.Header().Get("ETag"); w.Status() == http.StatusNotModified && etag != "" {
+		if encoded := variantETag(etag, w.encoding); matchETag(w.ifNoneMatch, encoded, false) {
+			w.Header().Set("ETag", encoded)
+		}
+	}
+
+	if w.body.Len() == 0 {
+		if w.wroteHeader || final {
+			w.ResponseWriter.WriteHeaderNow()
+		}
+		return nil
+	}
+	body := w.body.Bytes()
+	w.body = bytes.Buffer{}
+	_, err := w.Write(body)
+	return err
+}
+
+func (w *compressWriter) compressible(final bool) bool {
+	if final && w.body.Len() < w.minSize {
+		return false
+	}
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusPartialContent,
+		status == http.StatusNotModified:
+		return false
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || header.Get("Content-Range") != "" {
+		return false
+	}
+	if strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-transform") {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, prefix := range w.excluded {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// finish writes a body that never reached the threshold and ends the encoded stream
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.encoder == nil {
+		metrics.CompressedResponses.Add("identity", 1)
+		return
+	}
+
+	w.encoder.Close()
+	w.encoder.Reset(io.Discard)
+	encoders[w.encoding].Put(w.encoder)
+	w.encoder = nil
+
+	metrics.CompressedResponses.Add(w.encoding, 1)
+	metrics.CompressedBytes.Add(w.encoding+"_in", w.in)
+	metrics.CompressedBytes.Add(w.encoding+"_out", w.out.n)
+}
+
+// countingWriter counts the bytes an encoder writes
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.w.Write(b)
+	w.n += int64(n)
+	return n, err
+}
+
+// DecompressBody decodes a request body sent with a gzip, br or zstd Content-Encoding. Handlers limit
+// the body they read, which then counts decoded bytes, so a small upload cannot expand without bound
+func DecompressBody() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := strings.ToLower(strings.TrimSpace(c.GetHeader("Content-Encoding")))
+		if encoding == "" || encoding == "identity" {
+			c.Next()
+			return
+		}
+
+		var body io.ReadCloser
+		switch encoding {
+		case encodingGzip, "x-gzip":
+			r, err := gzip.NewReader(c.Request.Body)
+			if err != nil {
+				response.Error(c, http.StatusBadRequest, "body is not valid gzip")
+				return
+			}
+			body = r
+		case encodingBrotli:
+			body = io.NopCloser(brotli.NewReader(c.Request.Body))
+		case encodingZstd:
+			r, err := zstd.NewReader(c.Request.Body, zstd.WithDecoderConcurrency(1))
+			if err != nil {
+				response.Error(c, http.StatusBadRequest, "body is not valid zstd")
+				return
+			}
+			body = r.IOReadCloser()
+		default:
+			c.Header("Accept-Encoding", strings.Join([]string{encodingGzip, encodingBrotli, encodingZstd}, ", "))
+			response.Error(c, http.StatusUnsupportedMediaType, "Content-Encoding must be gzip, br or zstd")
+			return
+		}
+		defer body.Close()
+
+		c.Request.Body = body
+		c.Request.Header.Del("Content-Encoding")
+		c.Request.Header.Del("Content-Length")
+		c.Request.ContentLength = -1
+		c.Next()
+	}
+}
+
+// splitList splits a comma separated setting, blank items are dropped
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
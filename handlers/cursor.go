@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pagingParams are the query params that move through a list rather than filter it
+var pagingParams = []string{"cursor", "offset", "limit"}
+
+// cursor is the position encoded in an opaque ?cursor= value
+type cursor struct {
+	Offset  int   `json:"o"`
+	Limit   int   `json:"l"`
+	Expires int64 `json:"e,omitempty"` // unix seconds, set from the configured cursor TTL
+}
+
+// page is the window a list request asks for
+type page struct {
+	Offset int
+	Limit  int
+}
+
+// parsePage reads either a signed cursor or the legacy offset param, a cursor keeps its limit unless limit is given
+func (h Handler) parsePage(c *gin.Context) (page, error) {
+	raw := c.Query("cursor")
+	if raw == "" {
+		offset, limit, err := h.parseOffsetLimit(c)
+		return page{Offset: offset, Limit: limit}, err
+	}
+	if _, ok := c.GetQuery("offset"); ok {
+		return page{}, errors.New("cursor and offset cannot be used together")
+	}
+
+	cur, err := h.decodeCursor(c, raw)
+	if err != nil {
+		return page{}, err
+	}
+	p := page{Offset: cur.Offset, Limit: cur.Limit}
+	if _, ok := c.GetQuery("limit"); ok {
+		if _, p.Limit, err = h.parseOffsetLimit(c); err != nil {
+			return page{}, err
+		}
+	}
+	return p, nil
+}
+
+// lookahead is the limit to ask the backend for, one more than the page so the next page can be detected
+func lookahead(limit int) int {
+	if limit == 0 {
+		return 0
+	}
+	return limit + 1
+}
+
+// trimPage drops the lookahead item from n fetched items
+func trimPage(n, limit int) (count int, hasNext bool) {
+	if limit > 0 && n > limit {
+		return limit, true
+	}
+	return n, false
+}
+
+// writePage answers with a page of count items and links to its neighbours, hasNext tells whether the
+// backend had more items past this page and total is only known when the gateway paged the list itself
+func (h Handler) writePage(c *gin.Context, message string, data interface{}, p page, count int, hasNext bool, total *int) {
+	meta := models.Pagination{
+		Offset: p.Offset,
+		Limit:  p.Limit,
+		Count:  count,
+		Total:  total,
+	}
+
+	var links []string
+	if hasNext && p.Limit > 0 {
+		meta.Next = h.pageURL(c, cursor{Offset: p.Offset + p.Limit, Limit: p.Limit})
+		links = append(links, "<"+meta.Next+`>; rel="next"`)
+	}
+	if p.Offset > 0 && p.Limit > 0 {
+		prev := p.Offset - p.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		meta.Prev = h.pageURL(c, cursor{Offset: prev, Limit: p.Limit})
+		links = append(links, "<"+meta.Prev+`>; rel="prev"`)
+	}
+	if len(links) > 0 {
+		c.Header("Link", strings.Join(links, ", "))
+	}
+
+	response.List(c, message, data, meta)
+}
+
+// pageURL is the current request with its paging params replaced by cur
+func (h Handler) pageURL(c *gin.Context, cur cursor) string {
+	query := c.Request.URL.Query()
+	for _, param := range pagingParams {
+		query.Del(param)
+	}
+	query.Set("cursor", h.encodeCursor(c, cur))
+	query.Set("limit", strconv.Itoa(cur.Limit))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
+
+// encodeCursor signs cur together with the route and its filters so it cannot be forged or replayed on another query
+func (h Handler) encodeCursor(c *gin.Context, cur cursor) string {
+	if h.Conf.CursorTTL > 0 {
+		cur.Expires = time.Now().Add(h.Conf.CursorTTL).Unix()
+	}
+	payload, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(h.signCursor(c, payload))
+}
+
+func (h Handler) decodeCursor(c *gin.Context, raw string) (cursor, error) {
+	invalid := errors.New("cursor is invalid or does not belong to this query")
+
+	parts := strings.SplitN(raw, ".", 2)
+	if len(parts) != 2 {
+		return cursor{}, invalid
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return cursor{}, invalid
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, h.signCursor(c, payload)) {
+		return cursor{}, invalid
+	}
+
+	var cur cursor
+	if err := json.Unmarshal(payload, &cur); err != nil || cur.Offset < 0 || cur.Limit < 0 {
+		return cursor{}, invalid
+	}
+	if cur.Expires != 0 && time.Now().Unix() > cur.Expires {
+		return cursor{}, errors.New("cursor has expired, start over from the first page")
+	}
+	if h.Conf.MaxLimit > 0 && cur.Limit > h.Conf.MaxLimit {
+		cur.Limit = h.Conf.MaxLimit
+	}
+	return cur, nil
+}
+
+func (h Handler) signCursor(c *gin.Context, payload []byte) []byte {
+	filters := c.Request.URL.Query()
+	for _, param := range pagingParams {
+		filters.Del(param)
+	}
+
+	mac := hmac.New(sha256.New, h.cursorKey)
+	mac.Write(payload)
+	mac.Write([]byte("|" + c.Request.URL.Path + "?" + filters.Encode()))
+	return mac.Sum(nil)[:16]
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"blogpost/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func cursorContext(target string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", target, nil)
+	return c
+}
+
+func newCursorHandler(t *testing.T, secret string, ttl time.Duration) Handler {
+	t.Helper()
+	h, err := NewHandler(config.Config{CursorSecret: secret, CursorTTL: ttl, MaxLimit: 100}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	h := newCursorHandler(t, "secret", time.Hour)
+	raw := h.encodeCursor(cursorContext("/v1/article?sort=title"), cursor{Offset: 20, Limit: 10})
+
+	got, err := h.decodeCursor(cursorContext("/v1/article?sort=title&cursor=x&limit=5"), raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Offset != 20 || got.Limit != 10 {
+		t.Errorf("decodeCursor() = %+v, want offset 20 and limit 10", got)
+	}
+}
+
+func TestCursorRejected(t *testing.T) {
+	h := newCursorHandler(t, "secret", time.Hour)
+	issuedAt := "/v1/article?sort=title"
+	raw := h.encodeCursor(cursorContext(issuedAt), cursor{Offset: 20, Limit: 10})
+
+	// tampered swaps the payload for one with another offset and keeps the signature
+	payload, _ := json.Marshal(cursor{Offset: 0, Limit: 10, Expires: time.Now().Add(time.Hour).Unix()})
+	tampered := base64.RawURLEncoding.EncodeToString(payload) + raw[strings.Index(raw, "."):]
+
+	past, _ := json.Marshal(cursor{Offset: 20, Limit: 10, Expires: time.Now().Add(-time.Minute).Unix()})
+	expired := base64.RawURLEncoding.EncodeToString(past) + "." +
+		base64.RawURLEncoding.EncodeToString(h.signCursor(cursorContext(issuedAt), past))
+
+	tests := []struct {
+		name    string
+		handler Handler
+		target  string
+		raw     string
+	}{
+		{name: "tampered payload", handler: h, target: issuedAt, raw: tampered},
+		{name: "tampered signature", handler: h, target: issuedAt, raw: raw[:len(raw)-2] + "AA"},
+		{name: "no signature", handler: h, target: issuedAt, raw: raw[:strings.Index(raw, ".")]},
+		{name: "wrong secret", handler: newCursorHandler(t, "other", time.Hour), target: issuedAt, raw: raw},
+		{name: "expired", handler: h, target: issuedAt, raw: expired},
+		{name: "other sort order", handler: h, target: "/v1/article?sort=created_at", raw: raw},
+		{name: "other order", handler: h, target: "/v1/article?sort=title&order=desc", raw: raw},
+		{name: "other route", handler: h, target: "/v1/author?sort=title", raw: raw},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.handler.decodeCursor(cursorContext(tt.target), tt.raw); err == nil {
+				t.Errorf("decodeCursor() = %+v, want an error", got)
+			}
+		})
+	}
+}
+
+func TestCursorWithoutSecretIsRandomPerHandler(t *testing.T) {
+	a, b := newCursorHandler(t, "", 0), newCursorHandler(t, "", 0)
+	c := cursorContext("/v1/article")
+	raw := a.encodeCursor(c, cursor{Offset: 10, Limit: 10})
+
+	if _, err := a.decodeCursor(c, raw); err != nil {
+		t.Errorf("a cursor is refused by the handler that issued it: %v", err)
+	}
+	if _, err := b.decodeCursor(c, raw); err == nil {
+		t.Error("two handlers without CURSOR_SECRET share a key")
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"blogpost/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks a route as a deprecated alias of successor: it sets the Deprecation (RFC 9745)
+// and Sunset (RFC 8594) headers, links the successor and counts the use of the route
+func (h Handler) Deprecated(successor string) gin.HandlerFunc {
+	deprecatedAt, _ := time.Parse("2006-01-02", h.Conf.LegacyRoutesDeprecatedAt)
+	sunset, _ := time.Parse("2006-01-02", h.Conf.LegacyRoutesSunset)
+
+	return func(c *gin.Context) {
+		metrics.DeprecatedRoutes.Add(c.Request.Method+" "+c.FullPath(), 1)
+
+		if !deprecatedAt.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", deprecatedAt.Unix()))
+		} else {
+			c.Header("Deprecation", "true")
+		}
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		c.Header("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+
+		c.Next()
+	}
+}
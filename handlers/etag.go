@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"blogpost/genprotos/article"
+	"blogpost/genprotos/author"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// makeETag derives a strong entity tag from the resource id and its last modification time,
+// it names the JSON document of the resource and is told apart from its other representations by a suffix
+func makeETag(id, updatedAt, createdAt string) string {
+	version := updatedAt
+	if version == "" {
+		version = createdAt
+	}
+	sum := sha1.Sum([]byte(id + "|" + version))
+	return `"` + hex.EncodeToString(sum[:10]) + `"`
+}
+
+func articleETag(a *article.GetArticleByIdRes) string {
+	return makeETag(a.GetId(), a.GetUpdatedAt(), a.GetCreatedAt())
+}
+
+func authorETag(a *author.GetAuthorByIdRes) string {
+	return makeETag(a.GetId(), a.GetUpdatedAt(), a.GetCreatedAt())
+}
+
+// matchETag reports whether etag is in a comma separated If-Match or If-None-Match list,
+// weak tags compare equal to strong ones unless strong is set
+func matchETag(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.HasPrefix(candidate, "W/") {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag header and answers 304 when If-None-Match already has it
+func notModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	if header := c.GetHeader("If-None-Match"); header != "" && matchETag(header, etag, false) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// notModifiedSince is notModified for responses that also carry Last-Modified, If-Modified-Since
+// is only looked at when the request has no If-None-Match
+func notModifiedSince(c *gin.Context, etag string, modified time.Time) bool {
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if header := c.GetHeader("If-None-Match"); header != "" {
+		if etag != "" && matchETag(header, etag, false) {
+			c.AbortWithStatus(http.StatusNotModified)
+			return true
+		}
+		return false
+	}
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	if err == nil && !modified.IsZero() && !modified.Truncate(time.Second).After(since) {
+		c.AbortWithStatus(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatch answers 412 when the request carries If-Match and the current etag is not in it
+func ifMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" || matchETag(header, etag, true) {
+		return true
+	}
+	response.Error(c, http.StatusPreconditionFailed, "the resource has been modified, fetch it again before changing it")
+	return false
+}
+
+// articleIfMatch re-reads the article only when the request is conditional
+func (h Handler) articleIfMatch(c *gin.Context, id string) bool {
+	if c.GetHeader("If-Match") == "" {
+		return true
+	}
+
+	current, err := h.grpcClients.Article.GetArticleByID(c.Request.Context(), &article.GetArticleByIdReq{
+		Id: id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return false
+	}
+	return ifMatch(c, articleETag(current))
+}
+
+// authorIfMatch re-reads the author only when the request is conditional
+func (h Handler) authorIfMatch(c *gin.Context, id string) bool {
+	if c.GetHeader("If-Match") == "" {
+		return true
+	}
+
+	current, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+		Id: id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return false
+	}
+	return ifMatch(c, authorETag(current))
+}
+
+// The layers that change the bytes of a representation add a suffix inside the quotes of its entity tag:
+// Negotiate one for the media type and ?fields=, Compress the content coding. Handlers and the cache only
+// see the tags of the resource, each layer strips its suffix from the conditional headers on the way in
+
+// etagWriter adds suffix to the ETag of the response before its header is written
+type etagWriter struct {
+	gin.ResponseWriter
+	suffix string
+	done   bool
+}
+
+func (w *etagWriter) tag() {
+	if w.done {
+		return
+	}
+	w.done = true
+	if etag := w.Header().Get("ETag"); etag != "" {
+		w.Header().Set("ETag", variantETag(etag, w.suffix))
+	}
+}
+
+func (w *etagWriter) WriteHeaderNow() {
+	w.tag()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	w.tag()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *etagWriter) WriteString(s string) (int, error) {
+	w.tag()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *etagWriter) Flush() {
+	w.tag()
+	w.ResponseWriter.Flush()
+}
+
+// unwrapConditionals strips a layer's suffix from the tags of If-None-Match and If-Match. isLayer tells the
+// layer's suffixes apart, suffix is the one of this response. An If-None-Match tag of another representation
+// is dropped as it cannot match, one without a suffix still matches when plainMatches is set.
+// If-Match is about the version of the resource, so any of the layer's suffixes is stripped from it
+func unwrapConditionals(req *http.Request, suffix string, plainMatches bool, isLayer func(string) bool) {
+	if header := req.Header.Get("If-None-Match"); header != "" {
+		var kept []string
+		for _, candidate := range strings.Split(header, ",") {
+			base, layer, ok := cutETagSuffix(strings.TrimSpace(candidate), isLayer)
+			switch {
+			case base == "*":
+				kept = append(kept, base)
+			case ok && layer == suffix, !ok && (plainMatches || suffix == ""):
+				kept = append(kept, base)
+			}
+		}
+		if len(kept) == 0 {
+			req.Header.Del("If-None-Match")
+		} else {
+			req.Header.Set("If-None-Match", strings.Join(kept, ", "))
+		}
+	}
+
+	if header := req.Header.Get("If-Match"); header != "" {
+		candidates := strings.Split(header, ",")
+		for i, candidate := range candidates {
+			candidates[i], _, _ = cutETagSuffix(strings.TrimSpace(candidate), isLayer)
+		}
+		req.Header.Set("If-Match", strings.Join(candidates, ", "))
+	}
+}
+
+// cutETagSuffix splits the last suffix off an entity tag when isLayer accepts it
+func cutETagSuffix(etag string, isLayer func(string) bool) (base, suffix string, ok bool) {
+	if !strings.HasSuffix(etag, `"`) {
+		return etag, "", false
+	}
+	inner := strings.TrimSuffix(etag, `"`)
+	i := strings.LastIndexByte(inner, '-')
+	if i < 0 || !isLayer(inner[i+1:]) {
+		return etag, "", false
+	}
+	return inner[:i] + `"`, inner[i+1:], true
+}
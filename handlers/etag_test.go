@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blogpost/codec"
+	"blogpost/config"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const testETag = `"0123456789abcdef0123"`
+
+func newETagRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := Handler{Conf: config.Config{CompressEncodings: "gzip"}}
+	router := gin.New()
+	router.Use(h.Compress(), Negotiate(), SanitizeResponse())
+	router.GET("/author", SparseFields(models.Author{}), func(c *gin.Context) {
+		if notModified(c, testETag) {
+			return
+		}
+		response.OK(c, http.StatusOK, "OK", models.Author{ID: "1", Fullname: "Jane Doe"})
+	})
+	router.PUT("/author", func(c *gin.Context) {
+		if !ifMatch(c, testETag) {
+			return
+		}
+		c.Header("ETag", testETag)
+		response.OK(c, http.StatusOK, "OK", models.Author{ID: "1", Fullname: "Jane Doe"})
+	})
+	return router
+}
+
+func etagRequest(router http.Handler, method, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestETagNamesTheRepresentation(t *testing.T) {
+	router := newETagRouter()
+
+	json := etagRequest(router, http.MethodGet, "/author", nil).Header().Get("ETag")
+	if json != testETag {
+		t.Fatalf("ETag of the JSON document = %s, want %s", json, testETag)
+	}
+	fields := etagRequest(router, http.MethodGet, "/author?fields=id", nil).Header().Get("ETag")
+	xml := etagRequest(router, http.MethodGet, "/author", map[string]string{"Accept": codec.XML}).Header().Get("ETag")
+	msgpack := etagRequest(router, http.MethodGet, "/author", map[string]string{"Accept": codec.MsgPack}).Header().Get("ETag")
+
+	seen := map[string]string{json: "json"}
+	for name, etag := range map[string]string{"fields": fields, "xml": xml, "msgpack": msgpack} {
+		if etag == "" {
+			t.Fatalf("%s response has no ETag", name)
+		}
+		if other, ok := seen[etag]; ok {
+			t.Errorf("%s and %s responses share the ETag %s", name, other, etag)
+		}
+		seen[etag] = name
+	}
+}
+
+func TestIfNoneMatchComparesTheRepresentation(t *testing.T) {
+	router := newETagRouter()
+	xml := etagRequest(router, http.MethodGet, "/author", map[string]string{"Accept": codec.XML}).Header().Get("ETag")
+
+	tests := []struct {
+		name        string
+		accept      string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "json tag for json", ifNoneMatch: testETag, status: http.StatusNotModified},
+		{name: "weak json tag for json", ifNoneMatch: "W/" + testETag, status: http.StatusNotModified},
+		{name: "xml tag for xml", accept: codec.XML, ifNoneMatch: xml, status: http.StatusNotModified},
+		{name: "json tag for xml", accept: codec.XML, ifNoneMatch: testETag, status: http.StatusOK},
+		{name: "xml tag for json", ifNoneMatch: xml, status: http.StatusOK},
+		{name: "xml tag for msgpack", accept: codec.MsgPack, ifNoneMatch: xml, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := map[string]string{"If-None-Match": tt.ifNoneMatch}
+			if tt.accept != "" {
+				header["Accept"] = tt.accept
+			}
+			w := etagRequest(router, http.MethodGet, "/author", header)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if w.Code == http.StatusNotModified && w.Header().Get("ETag") != tt.ifNoneMatch && "W/"+w.Header().Get("ETag") != tt.ifNoneMatch {
+				t.Errorf("304 carries ETag %s, want %s", w.Header().Get("ETag"), tt.ifNoneMatch)
+			}
+		})
+	}
+}
+
+func TestIfMatchComparesTheVersionStrongly(t *testing.T) {
+	router := newETagRouter()
+	xml := etagRequest(router, http.MethodGet, "/author", map[string]string{"Accept": codec.XML}).Header().Get("ETag")
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		status  int
+	}{
+		{name: "json tag", ifMatch: testETag, status: http.StatusOK},
+		{name: "tag of another representation", ifMatch: xml, status: http.StatusOK},
+		{name: "weak tag", ifMatch: "W/" + testETag, status: http.StatusPreconditionFailed},
+		{name: "other version", ifMatch: `"ffffffffffffffffffff"`, status: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := etagRequest(router, http.MethodPut, "/author", map[string]string{"If-Match": tt.ifMatch})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestETagNamesTheContentCoding(t *testing.T) {
+	router := newETagRouter()
+	gzipped := map[string]string{"Accept-Encoding": "gzip"}
+
+	w := etagRequest(router, http.MethodGet, "/author", gzipped)
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", w.Header().Get("Content-Encoding"))
+	}
+	encoded := w.Header().Get("ETag")
+	if encoded != variantETag(testETag, "gzip") {
+		t.Fatalf("ETag of the gzip body = %s, want %s", encoded, variantETag(testETag, "gzip"))
+	}
+
+	gzipped["If-None-Match"] = encoded
+	w = etagRequest(router, http.MethodGet, "/author", gzipped)
+	if w.Code != http.StatusNotModified || w.Header().Get("ETag") != encoded {
+		t.Errorf("revalidating the gzip body: status %d ETag %s, want 304 %s", w.Code, w.Header().Get("ETag"), encoded)
+	}
+
+	w = etagRequest(router, http.MethodGet, "/author", map[string]string{"If-None-Match": encoded})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != testETag {
+		t.Errorf("gzip tag for the identity body: status %d ETag %s, want 200 %s", w.Code, w.Header().Get("ETag"), testETag)
+	}
+
+	xml := map[string]string{"Accept-Encoding": "gzip", "Accept": codec.XML}
+	encodedXML := etagRequest(router, http.MethodGet, "/author", xml).Header().Get("ETag")
+	if base, layer, _ := cutETagSuffix(encodedXML, isEncoding); layer != "gzip" || base == testETag {
+		t.Errorf("ETag of the gzip XML body = %s, want the XML tag with a gzip suffix", encodedXML)
+	}
+	xml["If-None-Match"] = encodedXML
+	if w := etagRequest(router, http.MethodGet, "/author", xml); w.Code != http.StatusNotModified {
+		t.Errorf("revalidating the gzip XML body: status %d, want 304", w.Code)
+	}
+
+	w = etagRequest(router, http.MethodPut, "/author", map[string]string{"If-Match": encodedXML})
+	if w.Code != http.StatusOK {
+		t.Errorf("If-Match with the gzip XML tag: status %d, want 200", w.Code)
+	}
+}
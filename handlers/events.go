@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpost/events"
+	"blogpost/response"
+	"blogpost/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// eventsRetry is the reconnection delay in milliseconds sent to event stream clients
+const eventsRetry = 3000
+
+// publish hands a change that succeeded to the event stream, userID is the user who made it
+func (h Handler) publish(eventType, authorID, userID string, data interface{}) {
+	h.broker.Publish(eventType, authorID, userID, data)
+}
+
+// Events godoc
+// @Summary     Stream changes
+// @Description server-sent events for every article and author created, updated or deleted through the gateway, the data is the resource as JSON.
+// @Description A client that reconnects with Last-Event-ID is sent the events it missed while the gateway still holds them,
+// @Description a reset event tells it to reload instead. Idle streams get a heartbeat comment
+// @Tags        events
+// @Produce     text/event-stream
+// @Param       types         query  string false "comma separated event types or resources, e.g. article.created,author"
+// @Param       author_id     query  string false "only changes of this author and of their articles"
+// @Param       mine          query  bool   false "true for only your own changes, false to leave them out"
+// @Param       Last-Event-ID header string false "id of the last event received"
+// @Param       Authorization header string false "Authorization"
+// @Success     200 {string} string "event stream"
+// @Failure     400 {object} models.Problem
+// @Failure     422 {object} models.Problem
+// @Router      /v1/events [get]
+func (h Handler) Events(c *gin.Context) {
+	filter, ok := parseEventFilter(c)
+	if !ok {
+		return
+	}
+
+	var lastID uint64
+	resume, unknown := false, false
+	if header := strings.TrimSpace(c.GetHeader("Last-Event-ID")); header != "" {
+		// an id of an earlier run or of another gateway instance says nothing about what this one has sent
+		lastID, resume = h.broker.ParseID(header)
+		unknown = !resume
+	}
+
+	missed, sub, complete := h.broker.Subscribe(lastID, resume)
+	defer sub.Close()
+	complete = complete && !unknown
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	// keeps proxies such as nginx from holding events back
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: %d\n\n", eventsRetry)
+	if !complete {
+		// the id moves the client past what it can no longer be sent
+		fmt.Fprintf(w, "id: %s\nevent: reset\ndata: {}\n\n", h.broker.FormatID(sub.Latest))
+	}
+	for _, e := range missed {
+		if filter.Match(e) {
+			h.writeEvent(w, e)
+		}
+	}
+	w.Flush()
+
+	interval := h.Conf.EventsHeartbeat
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, open := <-sub.Events():
+			if !open {
+				// dropped for falling behind, the client resumes from its last event
+				return
+			}
+			if !filter.Match(e) {
+				continue
+			}
+			if err := h.writeEvent(w, e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		w.Flush()
+	}
+}
+
+// parseEventFilter reads types, author_id and mine
+func parseEventFilter(c *gin.Context) (events.Filter, bool) {
+	var filter events.Filter
+	for _, t := range splitList(c.Query("types")) {
+		if !knownEventType(t) {
+			response.Error(c, http.StatusBadRequest, "types error: "+t+" is not an event type")
+			return filter, false
+		}
+		filter.Types = append(filter.Types, t)
+	}
+
+	if authorID := c.Query("author_id"); authorID != "" {
+		if errs := validation.Var("author_id", authorID, "uuid"); len(errs) > 0 {
+			response.ValidationError(c, "query is invalid", errs)
+			return filter, false
+		}
+		filter.AuthorID = authorID
+	}
+
+	if raw := c.Query("mine"); raw != "" {
+		mine, err := strconv.ParseBool(raw)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "mine error")
+			return filter, false
+		}
+		if mine {
+			filter.UserID = c.GetString("auth_user_id")
+		} else {
+			filter.NotUser = c.GetString("auth_user_id")
+		}
+	}
+	return filter, true
+}
+
+// knownEventType accepts an event type or the resource of some
+func knownEventType(t string) bool {
+	for _, known := range events.Types {
+		if t == known || strings.HasPrefix(known, t+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (h Handler) writeEvent(w io.Writer, e events.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", h.broker.FormatID(e.ID), e.Type, e.Data)
+	return err
+}
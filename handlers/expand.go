@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"blogpost/genprotos/author"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authorLoaderKey is the context key of the request's authorLoader
+const authorLoaderKey = "author_loader"
+
+// parseExpand reads ?expand=, author is the only relation articles can be expanded with
+func parseExpand(c *gin.Context) (expandAuthor bool, err error) {
+	for _, relation := range strings.Split(c.Query("expand"), ",") {
+		switch strings.TrimSpace(relation) {
+		case "":
+		case "author":
+			expandAuthor = true
+		default:
+			return false, errors.New("expand error")
+		}
+	}
+	return expandAuthor, nil
+}
+
+// expandArticles returns list as is or, with ?expand=author, with every article's author embedded.
+// Bodies are rendered with ?format=html, on failure it has already written the problem
+func (h Handler) expandArticles(c *gin.Context, list []models.Article) (interface{}, bool) {
+	expandAuthor, err := parseExpand(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	if !h.renderArticles(c, list) {
+		return nil, false
+	}
+	if !expandAuthor {
+		return list, true
+	}
+
+	ids := make([]string, 0, len(list))
+	for _, a := range list {
+		ids = append(ids, a.AuthorID)
+	}
+	authors, err := h.authorLoader(c).load(c.Request.Context(), ids)
+	if err != nil {
+		response.GRPCError(c, err)
+		return nil, false
+	}
+
+	packed := make([]models.PackedArticleModel, 0, len(list))
+	for _, a := range list {
+		packed = append(packed, models.NewPackedArticleFromArticle(a, authors[a.AuthorID]))
+	}
+	return packed, true
+}
+
+// storedArticles reports whether articles are answered as the services store them,
+// without expanded authors or rendered bodies
+func storedArticles(c *gin.Context) bool {
+	expandAuthor, err := parseExpand(c)
+	if err != nil || expandAuthor {
+		return false
+	}
+	html, err := parseRenderFormat(c)
+	return err == nil && !html
+}
+
+// authorLoader looks every author up at most once per request
+type authorLoader struct {
+	client  author.AuthorServicesClient
+	workers int
+
+	mu      sync.Mutex
+	authors map[string]models.Author
+}
+
+func (h Handler) authorLoader(c *gin.Context) *authorLoader {
+	if v, ok := c.Get(authorLoaderKey); ok {
+		return v.(*authorLoader)
+	}
+
+	workers := h.Conf.ExpandWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	loader := &authorLoader{
+		client:  h.grpcClients.Author,
+		workers: workers,
+		authors: make(map[string]models.Author),
+	}
+	c.Set(authorLoaderKey, loader)
+	return loader
+}
+
+// load returns the authors of ids, fetching the ones not seen yet on a bounded pool of workers.
+// An author that no longer exists is returned with only its id
+func (l *authorLoader) load(ctx context.Context, ids []string) (map[string]models.Author, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	missing := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := l.authors[id]; !ok && id != "" {
+			missing[id] = true
+		}
+	}
+
+	var resultMu sync.Mutex
+	err := fetchEach(ctx, l.workers, missing, func(ctx context.Context, id string) error {
+		found, err := l.fetch(ctx, id)
+		if err != nil {
+			return err
+		}
+		resultMu.Lock()
+		l.authors[id] = found
+		resultMu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	authors := make(map[string]models.Author, len(ids))
+	for _, id := range ids {
+		authors[id] = l.authors[id]
+	}
+	return authors, nil
+}
+
+// fetchEach calls fetch for every id on a pool of at most workers goroutines and stops at the first error
+func fetchEach(ctx context.Context, workers int, ids map[string]bool, fetch func(ctx context.Context, id string) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	queue := make(chan string)
+	var (
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	for i := 0; i < workers && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				if err := fetch(ctx, id); err != nil {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+						cancel()
+					}
+					errMu.Unlock()
+				}
+			}
+		}()
+	}
+	for id := range ids {
+		queue <- id
+	}
+	close(queue)
+	wg.Wait()
+	return firstErr
+}
+
+func (l *authorLoader) fetch(ctx context.Context, id string) (models.Author, error) {
+	found, err := l.client.GetAuthorByID(ctx, &author.Id{
+		Id: id,
+	})
+	if status.Code(err) == codes.NotFound {
+		return models.Author{ID: id}, nil
+	}
+	if err != nil {
+		return models.Author{}, err
+	}
+	return models.NewAuthorFromRes(found), nil
+}
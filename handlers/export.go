@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"blogpost/genprotos/author"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// ExportErrorTrailer reports an export that failed after the first records were sent
+	ExportErrorTrailer = "X-Export-Error"
+	// ExportCountTrailer is the number of records an export sent
+	ExportCountTrailer = "X-Export-Count"
+)
+
+// exportFormat is how records are written for one value of ?format=
+type exportFormat struct {
+	contentType string
+	extension   string
+	csv         bool
+	gzip        bool
+}
+
+var exportFormats = map[string]exportFormat{
+	"jsonl":       {contentType: "application/x-ndjson", extension: "jsonl"},
+	"csv":         {contentType: "text/csv; charset=utf-8", extension: "csv", csv: true},
+	"ndjson-gzip": {contentType: "application/gzip", extension: "ndjson.gz", gzip: true},
+}
+
+// exportPage reads the records of the backend page starting at offset, done ends the export
+type exportPage func(ctx context.Context, offset, batch int) (records []interface{}, done bool, err error)
+
+// export describes one downloadable collection
+type export struct {
+	name   string
+	header []string
+	row    func(record interface{}) []string
+	page   exportPage
+}
+
+// ExportArticles godoc
+// @Summary     Export articles
+// @Description stream every article as JSON Lines, CSV or gzipped NDJSON, the list is read from the article service page by page.
+// @Description The filters of GET /v1/article apply, sort does not as the export is never held in memory.
+// @Description An error after the first records is reported in the X-Export-Error trailer
+// @Tags        articles
+// @Produce     application/x-ndjson
+// @Produce     text/csv
+// @Produce     application/gzip
+// @Param       format         query    string false "jsonl (default), csv or ndjson-gzip"
+// @Param       search         query    string false "search"
+// @Param       author_id      query    string false "only articles of this author"
+// @Param       created_after  query    string false "date or RFC 3339 time"
+// @Param       created_before query    string false "date or RFC 3339 time"
+// @Param       filter         query    string false "clauses joined by and, e.g. created_at ge 2024-01-01 and title contains go"
+// @Param       Authorization  header   string false "Authorization"
+// @Success     200            {array}  models.Article
+// @Header      200            {string} Content-Disposition "attachment with the file name"
+// @Failure     400            {object} models.Problem
+// @Router      /v1/article/export [get]
+func (h Handler) ExportArticles(c *gin.Context) {
+	q, err := parseArticleQuery(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.sort != "" {
+		response.Error(c, http.StatusBadRequest, "sort error: exports keep the service order")
+		return
+	}
+	search := c.Query("search")
+
+	page := func(ctx context.Context, offset, batch int) ([]interface{}, bool, error) {
+		// whatever the service leaves unfiltered is filtered here
+		req, _ := q.listRequest(search)
+		req.Offset = int32(offset)
+		req.Limit = int32(batch)
+		res, err := h.grpcClients.Article.GetArticleList(ctx, req)
+		if err != nil {
+			return nil, false, err
+		}
+		return q.matching(models.NewArticleList(res.GetArticles())), len(res.GetArticles()) < batch, nil
+	}
+	if authorID, ok := q.authorScope(); ok && search == "" {
+		// the author service answers with all of the author's articles at once
+		page = func(ctx context.Context, offset, batch int) ([]interface{}, bool, error) {
+			res, err := h.grpcClients.Author.GetArticlesByAuthorID(ctx, &author.Id{Id: authorID})
+			if err != nil {
+				return nil, false, err
+			}
+			return q.matching(models.NewArticleListFromAuthorArticles(res.GetArticles())), true, nil
+		}
+	}
+
+	h.export(c, export{
+		name:   "articles",
+		header: []string{"id", "title", "body", "author_id", "created_at", "updated_at"},
+		row: func(record interface{}) []string {
+			a := record.(models.Article)
+			return []string{a.ID, a.Title, a.Body, a.AuthorID, exportTime(&a.CreatedAt), exportTime(a.UpdatedAt)}
+		},
+		page: page,
+	})
+}
+
+// ExportAuthors godoc
+// @Summary     Export authors
+// @Description stream every author as JSON Lines, CSV or gzipped NDJSON, the list is read from the author service page by page.
+// @Description An error after the first records is reported in the X-Export-Error trailer
+// @Tags        authors
+// @Produce     application/x-ndjson
+// @Produce     text/csv
+// @Produce     application/gzip
+// @Param       format        query    string false "jsonl (default), csv or ndjson-gzip"
+// @Param       search        query    string false "search"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {array}  models.Author
+// @Header      200           {string} Content-Disposition "attachment with the file name"
+// @Failure     400           {object} models.Problem
+// @Router      /v1/author/export [get]
+func (h Handler) ExportAuthors(c *gin.Context) {
+	search := c.Query("search")
+
+	h.export(c, export{
+		name:   "authors",
+		header: []string{"id", "fullname", "created_at", "updated_at"},
+		row: func(record interface{}) []string {
+			a := record.(models.Author)
+			return []string{a.ID, a.Fullname, exportTime(&a.CreatedAt), exportTime(a.UpdatedAt)}
+		},
+		page: func(ctx context.Context, offset, batch int) ([]interface{}, bool, error) {
+			res, err := h.grpcClients.Author.GetAuthorList(ctx, &author.GetAuthorListReq{
+				Offset: int64(offset),
+				Limit:  int64(batch),
+				Search: search,
+			})
+			if err != nil {
+				return nil, false, err
+			}
+			authors := models.NewAuthorList(res.GetAuthors())
+			records := make([]interface{}, len(authors))
+			for i, a := range authors {
+				records[i] = a
+			}
+			return records, len(authors) < batch, nil
+		},
+	})
+}
+
+// export streams e.page by page, each page is flushed to the client before the next one is read.
+// The first page is read before anything is sent so a failing backend still gets a problem response
+func (h Handler) export(c *gin.Context, e export) {
+	format, ok := exportFormats[c.DefaultQuery("format", "jsonl")]
+	if !ok {
+		response.Error(c, http.StatusBadRequest, "format error")
+		return
+	}
+	batch := h.Conf.MaxLimit
+	if batch <= 0 {
+		batch = 100
+	}
+
+	ctx := c.Request.Context()
+	records, done, err := e.page(ctx, 0, batch)
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", format.contentType)
+	header.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`,
+		e.name, time.Now().UTC().Format("20060102"), format.extension))
+	header.Set("Cache-Control", "no-store")
+	header.Set("Trailer", ExportErrorTrailer+", "+ExportCountTrailer)
+	c.Status(http.StatusOK)
+
+	var out io.Writer = c.Writer
+	var zw *gzip.Writer
+	if format.gzip {
+		zw = gzip.NewWriter(c.Writer)
+		out = zw
+	}
+	w := newExportWriter(out, format, e)
+	if format.csv {
+		w.csv.Write(e.header)
+	}
+
+	count := 0
+	for offset := 0; ; {
+		for _, record := range records {
+			if err = w.write(record); err != nil {
+				break
+			}
+			count++
+		}
+		if err == nil {
+			err = w.flush()
+		}
+		if zw != nil && err == nil {
+			err = zw.Flush()
+		}
+		c.Writer.Flush()
+		if err != nil || done {
+			break
+		}
+
+		offset += batch
+		records, done, err = e.page(ctx, offset, batch)
+		if err != nil {
+			break
+		}
+	}
+	if zw != nil {
+		zw.Close()
+	}
+
+	if err != nil {
+		c.Error(err)
+		header.Set(ExportErrorTrailer, err.Error())
+	}
+	header.Set(ExportCountTrailer, strconv.Itoa(count))
+}
+
+// exportWriter writes records as CSV rows or as one JSON document per line
+type exportWriter struct {
+	json *json.Encoder
+	csv  *csv.Writer
+	row  func(record interface{}) []string
+}
+
+func newExportWriter(out io.Writer, format exportFormat, e export) *exportWriter {
+	if format.csv {
+		return &exportWriter{csv: csv.NewWriter(out), row: e.row}
+	}
+	return &exportWriter{json: json.NewEncoder(out)}
+}
+
+func (w *exportWriter) write(record interface{}) error {
+	if w.csv != nil {
+		return w.csv.Write(w.row(record))
+	}
+	return w.json.Encode(record)
+}
+
+func (w *exportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+func exportTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"blogpost/clients"
+	"blogpost/config"
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+	"blogpost/models"
+	"blogpost/validation"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testToken    = "test-token"
+	testPassword = "hashed-s3cret"
+)
+
+var testUser = &authorization.User{
+	Id:        "2b5f7d4e-3a91-4c55-9f0e-6a1c3b8d2e10",
+	Username:  "alice",
+	Password:  testPassword,
+	UserType:  "user",
+	CreatedAt: "2026-01-02T03:04:05Z",
+}
+
+func TestMain(m *testing.M) {
+	if err := validation.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeAuthClient answers like the authorization service for testUser, the embedded client panics on anything else
+type fakeAuthClient struct {
+	authorization.AuthServiceClient
+}
+
+func (fakeAuthClient) HasAccess(ctx context.Context, in *authorization.TokenRequest, opts ...grpc.CallOption) (*authorization.HasAccessResponse, error) {
+	if in.GetToken() != testToken {
+		return &authorization.HasAccessResponse{}, nil
+	}
+	return &authorization.HasAccessResponse{User: testUser, HasAccess: true}, nil
+}
+
+func (fakeAuthClient) Login(ctx context.Context, in *authorization.LoginRequest, opts ...grpc.CallOption) (*authorization.TokenResponse, error) {
+	if in.GetUsername() != testUser.Username || in.GetPassword() != testPassword {
+		return nil, status.Error(codes.Unauthenticated, "wrong username or password")
+	}
+	return &authorization.TokenResponse{Token: testToken}, nil
+}
+
+func (fakeAuthClient) UpdateUser(ctx context.Context, in *authorization.UpdateUserRequest, opts ...grpc.CallOption) (*authorization.User, error) {
+	updated := proto.Clone(testUser).(*authorization.User)
+	updated.Password = in.GetPassword()
+	return updated, nil
+}
+
+func (fakeAuthClient) GetUserByID(ctx context.Context, in *authorization.GetUserByIDRequest, opts ...grpc.CallOption) (*authorization.User, error) {
+	return testUser, nil
+}
+
+// fakeAuthorClient holds one author sharing testUser's ID with one article
+type fakeAuthorClient struct {
+	author.AuthorServicesClient
+}
+
+func (fakeAuthorClient) GetAuthorByID(ctx context.Context, in *author.Id, opts ...grpc.CallOption) (*author.GetAuthorByIdRes, error) {
+	if in.GetId() != testUser.Id {
+		return nil, status.Error(codes.NotFound, "author not found")
+	}
+	return &author.GetAuthorByIdRes{Id: testUser.Id, Fullname: "Alice", CreatedAt: testUser.CreatedAt}, nil
+}
+
+func (fakeAuthorClient) GetArticlesByAuthorID(ctx context.Context, in *author.Id, opts ...grpc.CallOption) (*author.GetArticles, error) {
+	return &author.GetArticles{Articles: []*author.Article{{
+		Id:        "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		Content:   &author.Post{Title: "Hello", Body: "World"},
+		AuthorId:  in.GetId(),
+		CreatedAt: testUser.CreatedAt,
+	}}}, nil
+}
+
+// newTestRouter mounts the user routes behind the same middleware as main
+func newTestRouter() *gin.Engine {
+	return newTestRouterWith(fakeAuthorClient{})
+}
+
+func newTestRouterWith(authorClient author.AuthorServicesClient) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	conf := config.Config{GraphQLMaxDepth: 8, GraphQLMaxComplexity: 250}
+	h, err := NewHandler(conf, &clients.GrpcClients{
+		Authorization: fakeAuthClient{},
+		Author:        authorClient,
+	}, nil)
+	if err != nil {
+		panic(err)
+	}
+
+	router := gin.New()
+	router.Use(RequestID())
+	v1 := router.Group("/v1")
+	v1.Use(Negotiate(), SanitizeResponse())
+	v1.POST("/login", h.Login)
+	v1.GET("/me", h.AuthMiddleware("*"), SparseFields(models.User{}), h.GetMe)
+	v1.PUT("/me/password", h.AuthMiddleware("*"), h.ChangeMyPassword)
+	v1.GET("/me/articles", h.AuthMiddleware("*"), h.GetMyArticles)
+	router.POST("/graphql", SanitizeResponse(), h.AuthMiddleware("*"), h.GraphQL())
+	h.SetEngine(router)
+	return router
+}
+
+// serve sends one request with testToken through the router
+func serve(t *testing.T, router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", testToken)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// assertNoSensitiveKeys fails when a sensitive key is anywhere in a JSON body or the password reached it at all
+func assertNoSensitiveKeys(t *testing.T, body []byte) {
+	t.Helper()
+	if bytes.Contains(body, []byte(testPassword)) {
+		t.Errorf("response contains the password: %s", body)
+	}
+
+	var payload interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, body)
+	}
+	var walk func(path string, v interface{})
+	walk = func(path string, v interface{}) {
+		switch value := v.(type) {
+		case map[string]interface{}:
+			for key, child := range value {
+				if _, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+					t.Errorf("response contains %s.%s: %s", path, key, body)
+				}
+				walk(path+"."+key, child)
+			}
+		case []interface{}:
+			for _, child := range value {
+				walk(path+"[]", child)
+			}
+		}
+	}
+	walk("$", payload)
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, w.Body.String())
+	}
+}
+
+// serveRequest sends req through the router as it is
+func serveRequest(router http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
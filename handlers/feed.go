@@ -0,0 +1,293 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpost/genprotos/article"
+	"blogpost/genprotos/author"
+	"blogpost/models"
+	"blogpost/response"
+	"blogpost/validation"
+
+	"github.com/gin-gonic/gin"
+)
+
+// feed is what an RSS or Atom document is rendered from. The article routes need a token,
+// so entries carry their content and a urn:uuid id rather than a link a reader could not follow
+type feed struct {
+	title    string
+	author   *models.Author
+	articles []models.Article // newest first
+	authors  map[string]models.Author
+}
+
+// ArticlesRSS godoc
+// @Summary     RSS feed of articles
+// @Description the newest articles as RSS 2.0, supports If-None-Match and If-Modified-Since
+// @Tags        feeds
+// @Produce     application/rss+xml
+// @Param       If-None-Match     header string false "ETag of a cached copy"
+// @Param       If-Modified-Since header string false "Last-Modified of a cached copy"
+// @Success     200 {string} string "RSS document"
+// @Header      200 {string} ETag "entity tag of the feed"
+// @Header      200 {string} Last-Modified "when the newest change in the feed happened"
+// @Router      /feeds/articles.rss [get]
+func (h Handler) ArticlesRSS(c *gin.Context) {
+	f, ok := h.articlesFeed(c)
+	if !ok {
+		return
+	}
+	h.writeRSS(c, f)
+}
+
+// ArticlesAtom godoc
+// @Summary     Atom feed of articles
+// @Description the newest articles as Atom, supports If-None-Match and If-Modified-Since
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Param       If-None-Match     header string false "ETag of a cached copy"
+// @Param       If-Modified-Since header string false "Last-Modified of a cached copy"
+// @Success     200 {string} string "Atom document"
+// @Header      200 {string} ETag "entity tag of the feed"
+// @Header      200 {string} Last-Modified "when the newest change in the feed happened"
+// @Router      /feeds/articles.atom [get]
+func (h Handler) ArticlesAtom(c *gin.Context) {
+	f, ok := h.articlesFeed(c)
+	if !ok {
+		return
+	}
+	h.writeAtom(c, f)
+}
+
+// AuthorAtom godoc
+// @Summary     Atom feed of an author
+// @Description the newest articles of one author as Atom, supports If-None-Match and If-Modified-Since
+// @Tags        feeds
+// @Produce     application/atom+xml
+// @Param       id                path   string true  "author id followed by .atom"
+// @Param       If-None-Match     header string false "ETag of a cached copy"
+// @Param       If-Modified-Since header string false "Last-Modified of a cached copy"
+// @Success     200 {string} string "Atom document"
+// @Header      200 {string} ETag "entity tag of the feed"
+// @Header      200 {string} Last-Modified "when the newest change in the feed happened"
+// @Failure     404 {object} models.Problem
+// @Failure     422 {object} models.Problem
+// @Router      /feeds/author/{id}.atom [get]
+func (h Handler) AuthorAtom(c *gin.Context) {
+	id := strings.TrimSuffix(c.Param("id"), ".atom")
+	if id == c.Param("id") || id == "" {
+		response.Error(c, http.StatusNotFound, "route not found")
+		return
+	}
+	if errs := validation.Var("id", id, "required,uuid"); len(errs) > 0 {
+		response.ValidationError(c, "path is invalid", errs)
+		return
+	}
+
+	found, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+		Id: id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+	articles, err := h.grpcClients.Author.GetArticlesByAuthorID(c.Request.Context(), &author.Id{
+		Id: id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	owner := models.NewAuthorFromRes(found)
+	list := models.NewArticleListFromAuthorArticles(articles.GetArticles())
+	h.writeAtom(c, feed{
+		title:    h.Conf.FeedTitle + " | " + owner.Fullname,
+		author:   &owner,
+		articles: newestArticles(list, h.Conf.FeedSize),
+		authors:  map[string]models.Author{owner.ID: owner},
+	})
+}
+
+// articlesFeed reads the newest articles of the blog and their authors, on failure it has already written the problem
+func (h Handler) articlesFeed(c *gin.Context) (feed, bool) {
+	list, err := h.latestArticles(c.Request.Context(), h.Conf.FeedSize)
+	if err != nil {
+		response.GRPCError(c, err)
+		return feed{}, false
+	}
+
+	ids := make([]string, 0, len(list))
+	for _, a := range list {
+		ids = append(ids, a.AuthorID)
+	}
+	authors, err := h.authorLoader(c).load(c.Request.Context(), ids)
+	if err != nil {
+		response.GRPCError(c, err)
+		return feed{}, false
+	}
+
+	return feed{
+		title:    h.Conf.FeedTitle,
+		articles: list,
+		authors:  authors,
+	}, true
+}
+
+// latestArticles reads one page of the n newest articles, the page is sorted again in case the service
+// does not honour the sort
+func (h Handler) latestArticles(ctx context.Context, n int) ([]models.Article, error) {
+	if n <= 0 {
+		n = h.Conf.MaxLimit
+	}
+	res, err := h.grpcClients.Article.GetArticleList(ctx, &article.GetArticleListReq{
+		Limit:  int32(n),
+		SortBy: "created_at",
+		Order:  "desc",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newestArticles(models.NewArticleList(res.GetArticles()), n), nil
+}
+
+// newestArticles sorts list by creation, newest first, and keeps at most n articles
+func newestArticles(list []models.Article, n int) []models.Article {
+	sortArticles(list, "created_at", "desc")
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+}
+
+// updated is when the newest change in the feed happened
+func (f feed) updated() time.Time {
+	var newest time.Time
+	if f.author != nil {
+		newest = updatedAtAuthor(*f.author)
+	}
+	for _, a := range f.articles {
+		if t := updatedAt(a); t.After(newest) {
+			newest = t
+		}
+	}
+	for _, a := range f.authors {
+		if t := updatedAtAuthor(a); t.After(newest) {
+			newest = t
+		}
+	}
+	return newest
+}
+
+func updatedAtAuthor(a models.Author) time.Time {
+	if a.UpdatedAt != nil {
+		return *a.UpdatedAt
+	}
+	return a.CreatedAt
+}
+
+// etag changes whenever an article of the feed or one of their authors does
+func (f feed) etag(format string) string {
+	hash := sha1.New()
+	hash.Write([]byte(format + "|" + f.title))
+	for _, a := range f.articles {
+		hash.Write([]byte("|" + a.ID + "|" + updatedAt(a).Format(time.RFC3339Nano) + "|" + f.authorName(a)))
+	}
+	return `"` + hex.EncodeToString(hash.Sum(nil)[:10]) + `"`
+}
+
+func (f feed) authorName(a models.Article) string {
+	if name := f.authors[a.AuthorID].Fullname; name != "" {
+		return name
+	}
+	return "unknown author"
+}
+
+func (h Handler) writeRSS(c *gin.Context, f feed) {
+	base := h.publicURL()
+	updated := f.updated()
+	if notModifiedSince(c, f.etag("rss"), updated) {
+		return
+	}
+
+	doc := models.RSS{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: models.RSSChannel{
+			Title:       f.title,
+			Link:        base + "/",
+			Self:        models.AtomLink{Href: base + c.Request.URL.Path, Rel: "self", Type: "application/rss+xml"},
+			Description: "The newest articles of " + f.title,
+			Items:       make([]models.RSSItem, 0, len(f.articles)),
+		},
+	}
+	if !updated.IsZero() {
+		doc.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, a := range f.articles {
+		doc.Channel.Items = append(doc.Channel.Items, models.RSSItem{
+			Title:       a.Title,
+			GUID:        models.RSSGUID{Value: "urn:uuid:" + a.ID},
+			Author:      f.authorName(a),
+			PubDate:     a.CreatedAt.UTC().Format(time.RFC1123Z),
+			Description: a.Body,
+		})
+	}
+	h.writeFeed(c, "application/rss+xml; charset=utf-8", doc)
+}
+
+func (h Handler) writeAtom(c *gin.Context, f feed) {
+	base := h.publicURL()
+	updated := f.updated()
+	if notModifiedSince(c, f.etag("atom"), updated) {
+		return
+	}
+
+	doc := models.AtomFeed{
+		ID:      base + c.Request.URL.Path,
+		Title:   f.title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []models.AtomLink{
+			{Href: base + c.Request.URL.Path, Rel: "self", Type: "application/atom+xml"},
+		},
+		Entries: make([]models.AtomEntry, 0, len(f.articles)),
+	}
+	if f.author != nil {
+		doc.Author = &models.AtomPerson{Name: f.author.Fullname}
+	}
+	for _, a := range f.articles {
+		doc.Entries = append(doc.Entries, models.AtomEntry{
+			ID:        "urn:uuid:" + a.ID,
+			Title:     a.Title,
+			Updated:   updatedAt(a).UTC().Format(time.RFC3339),
+			Published: a.CreatedAt.UTC().Format(time.RFC3339),
+			Author:    models.AtomPerson{Name: f.authorName(a)},
+			Content:   models.AtomContent{Type: "text", Value: a.Body},
+		})
+	}
+	h.writeFeed(c, "application/atom+xml; charset=utf-8", doc)
+}
+
+func (h Handler) writeFeed(c *gin.Context, contentType string, doc interface{}) {
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(h.Conf.FeedTTL.Seconds())))
+	c.Data(http.StatusOK, contentType, append([]byte(xml.Header), body...))
+}
+
+// publicURL is the scheme and host links to the gateway start with. It comes from the configuration only,
+// the Host of a request would end up in cached feeds
+func (h Handler) publicURL() string {
+	return strings.TrimSuffix(h.Conf.PublicURL, "/")
+}
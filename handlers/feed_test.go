@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"blogpost/cache"
+	"blogpost/clients"
+	"blogpost/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newFeedRouter(t *testing.T, articles *fakeArticleList) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	conf := config.Config{PublicURL: "https://blog.example/", FeedTitle: "Blog", FeedSize: 3, MaxLimit: 100}
+	h, err := NewHandler(conf, &clients.GrpcClients{Article: articles, Author: fakeAuthorClient{}}, cache.NewMemoryStore(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	feeds := router.Group("/feeds", h.CachedByPath(time.Minute, CacheArticles, CacheAuthors))
+	feeds.GET("/articles.rss", h.ArticlesRSS)
+	feeds.GET("/articles.atom", h.ArticlesAtom)
+	feeds.GET("/author/:id", h.AuthorAtom)
+	return router
+}
+
+func TestFeedLinksComeFromTheConfiguration(t *testing.T) {
+	router := newFeedRouter(t, &fakeArticleList{count: 10})
+
+	for _, target := range []string{"/feeds/articles.rss", "/feeds/articles.atom", "/feeds/author/" + testUser.Id + ".atom"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = "attacker.example"
+		req.Header.Set("X-Forwarded-Proto", "gopher")
+		w := serveRequest(router, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d: %s", target, w.Code, w.Body.String())
+		}
+		body := w.Body.String()
+		if strings.Contains(body, "attacker.example") || strings.Contains(body, "gopher:") {
+			t.Errorf("%s links to the request's host: %s", target, body)
+		}
+		if !strings.Contains(body, "https://blog.example"+target) {
+			t.Errorf("%s has no self link on the public URL: %s", target, body)
+		}
+		if strings.Contains(body, "/v1/") {
+			t.Errorf("%s links to routes that need a token: %s", target, body)
+		}
+		if !strings.Contains(body, "urn:uuid:") {
+			t.Errorf("%s has no entry ids: %s", target, body)
+		}
+	}
+}
+
+func TestLatestArticlesReadsOneSortedPage(t *testing.T) {
+	articles := &fakeArticleList{count: 1000}
+	router := newFeedRouter(t, articles)
+
+	if w := serveRequest(router, httptest.NewRequest(http.MethodGet, "/feeds/articles.atom", nil)); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if len(articles.requests) != 1 {
+		t.Fatalf("%d article list requests, want 1", len(articles.requests))
+	}
+	req := articles.requests[0]
+	if req.GetLimit() != 3 || req.GetSortBy() != "created_at" || req.GetOrder() != "desc" {
+		t.Errorf("request = %v, want the 3 newest articles", req)
+	}
+}
+
+func TestFeedCacheKeyLeavesOutTheQuery(t *testing.T) {
+	articles := &fakeArticleList{count: 5}
+	router := newFeedRouter(t, articles)
+
+	for i, target := range []string{"/feeds/articles.rss", "/feeds/articles.rss?junk=1", "/feeds/articles.rss?junk=2"} {
+		w := serveRequest(router, httptest.NewRequest(http.MethodGet, target, nil))
+		want := "HIT"
+		if i == 0 {
+			want = "MISS"
+		}
+		if got := w.Header().Get("X-Cache"); got != want {
+			t.Errorf("%s: X-Cache = %q, want %s", target, got, want)
+		}
+	}
+	if len(articles.requests) != 1 {
+		t.Errorf("%d article list requests, want 1", len(articles.requests))
+	}
+}
+
+func TestAuthorAtomChecksTheID(t *testing.T) {
+	router := newFeedRouter(t, &fakeArticleList{})
+	tests := []struct {
+		target string
+		status int
+	}{
+		{target: "/feeds/author/not-a-uuid.atom", status: http.StatusUnprocessableEntity},
+		{target: "/feeds/author/7c9e6679-7425-40de-944b-e07fc1f90ae7.atom", status: http.StatusNotFound},
+		{target: "/feeds/author/" + testUser.Id, status: http.StatusNotFound},
+		{target: "/feeds/author/" + testUser.Id + ".atom", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		if w := serveRequest(router, httptest.NewRequest(http.MethodGet, tt.target, nil)); w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.target, w.Code, tt.status)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fieldTree is a parsed ?fields= selection, a nil subtree keeps the whole value
+type fieldTree map[string]fieldTree
+
+// SparseFields trims the data of a successful response to the comma separated ?fields= paths, such as
+// id,title,author.fullname. Paths are checked against the JSON fields of models, the types data can hold
+func SparseFields(models ...interface{}) gin.HandlerFunc {
+	known := make(map[string]bool)
+	for _, model := range models {
+		jsonPaths(reflect.TypeOf(model), "", known)
+	}
+
+	return func(c *gin.Context) {
+		raw := c.Query("fields")
+		if raw == "" {
+			c.Next()
+			return
+		}
+		tree, err := parseFields(raw, known)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		w := newBufferedWriter(c.Writer)
+		c.Writer = w
+
+		c.Next()
+
+		c.Writer = w.ResponseWriter
+		body := w.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+		if w.Status() < http.StatusMultipleChoices {
+			body = selectFields(body, tree)
+		}
+		w.ResponseWriter.Write(body)
+	}
+}
+
+func parseFields(raw string, known map[string]bool) (fieldTree, error) {
+	tree := fieldTree{}
+	for _, path := range strings.Split(raw, ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if !known[path] {
+			return nil, fmt.Errorf("fields error: unknown field %q", path)
+		}
+
+		node := tree
+		parts := strings.Split(path, ".")
+		for i, part := range parts {
+			child, seen := node[part]
+			if seen && child == nil {
+				// a shorter path already keeps the whole value
+				break
+			}
+			if i == len(parts)-1 {
+				node[part] = nil
+				break
+			}
+			if child == nil {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree, nil
+}
+
+// selectFields keeps only the selected fields of the envelope's data, or returns body unchanged if it has none
+func selectFields(body []byte, tree fieldTree) []byte {
+	var payload map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return body
+	}
+	data, ok := payload["data"]
+	if !ok {
+		return body
+	}
+
+	payload["data"] = tree.project(data)
+	selected, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return selected
+}
+
+func (t fieldTree) project(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		projected := make(map[string]interface{}, len(t))
+		for key, subtree := range t {
+			child, ok := value[key]
+			if !ok {
+				continue
+			}
+			if subtree == nil {
+				projected[key] = child
+			} else {
+				projected[key] = subtree.project(child)
+			}
+		}
+		return projected
+	case []interface{}:
+		for i, child := range value {
+			value[i] = t.project(child)
+		}
+		return value
+	}
+	return v
+}
+
+// jsonPaths adds the dotted JSON paths of t to paths, embedded structs are flattened like encoding/json does
+func jsonPaths(t reflect.Type, prefix string, paths map[string]bool) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (field.PkgPath != "" && !field.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" {
+			jsonPaths(field.Type, prefix, paths)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		paths[prefix+name] = true
+		jsonPaths(field.Type, prefix+name+".", paths)
+	}
+}
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"blogpost/models"
+)
+
+// filterClause is one `field op value` comparison of a ?filter= expression such as
+// `title contains "go tips" and created_at ge 2024-01-01`
+type filterClause struct {
+	field string
+	op    string
+	value string
+	time  time.Time
+}
+
+// filterFields maps the filterable fields to whether they hold a time
+var filterFields = map[string]bool{
+	"title":      false,
+	"body":       false,
+	"author_id":  false,
+	"created_at": true,
+	"updated_at": true,
+}
+
+var (
+	stringOps = map[string]bool{"eq": true, "ne": true, "contains": true}
+	timeOps   = map[string]bool{"eq": true, "ne": true, "gt": true, "ge": true, "lt": true, "le": true}
+)
+
+// parseFilter parses clauses joined by `and`, a value with spaces is written in double quotes
+func parseFilter(expr string) ([]filterClause, error) {
+	tokens, err := filterTokens(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	var clauses []filterClause
+	for len(tokens) > 0 {
+		if len(clauses) > 0 {
+			if !strings.EqualFold(tokens[0], "and") {
+				return nil, fmt.Errorf("filter error: expected and, got %q", tokens[0])
+			}
+			tokens = tokens[1:]
+		}
+		if len(tokens) < 3 {
+			return nil, errors.New("filter error: a clause is field op value")
+		}
+
+		clause := filterClause{field: tokens[0], op: strings.ToLower(tokens[1]), value: tokens[2]}
+		isTime, ok := filterFields[clause.field]
+		if !ok {
+			return nil, fmt.Errorf("filter error: unknown field %q", clause.field)
+		}
+		if isTime {
+			if !timeOps[clause.op] {
+				return nil, fmt.Errorf("filter error: %q cannot be used on %s", clause.op, clause.field)
+			}
+			if clause.time, err = parseFilterTime(clause.value); err != nil {
+				return nil, fmt.Errorf("filter error: %s: %v", clause.field, err)
+			}
+		} else if !stringOps[clause.op] {
+			return nil, fmt.Errorf("filter error: %q cannot be used on %s", clause.op, clause.field)
+		}
+
+		clauses = append(clauses, clause)
+		tokens = tokens[3:]
+	}
+	return clauses, nil
+}
+
+func filterTokens(expr string) ([]string, error) {
+	var (
+		tokens []string
+		token  strings.Builder
+		quoted bool
+		inside bool
+	)
+	for _, r := range expr {
+		switch {
+		case r == '"':
+			if quoted {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inside = false
+			} else {
+				inside = true
+			}
+			quoted = !quoted
+		case unicode.IsSpace(r) && !quoted:
+			if inside {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inside = false
+			}
+		default:
+			token.WriteRune(r)
+			inside = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("filter error: unterminated quote")
+	}
+	if inside {
+		tokens = append(tokens, token.String())
+	}
+	return tokens, nil
+}
+
+// parseFilterTime accepts RFC 3339 timestamps and plain dates
+func parseFilterTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date nor an RFC 3339 time", s)
+	}
+	return t, nil
+}
+
+func (f filterClause) match(a models.Article) bool {
+	switch f.field {
+	case "title":
+		return matchString(a.Title, f.op, f.value)
+	case "body":
+		return matchString(a.Body, f.op, f.value)
+	case "author_id":
+		return matchString(a.AuthorID, f.op, f.value)
+	case "created_at":
+		return matchTime(a.CreatedAt, f.op, f.time)
+	case "updated_at":
+		return matchTime(updatedAt(a), f.op, f.time)
+	}
+	return false
+}
+
+func matchString(s, op, value string) bool {
+	switch op {
+	case "eq":
+		return s == value
+	case "ne":
+		return s != value
+	case "contains":
+		return strings.Contains(strings.ToLower(s), strings.ToLower(value))
+	}
+	return false
+}
+
+func matchTime(t time.Time, op string, value time.Time) bool {
+	switch op {
+	case "eq":
+		return t.Equal(value)
+	case "ne":
+		return !t.Equal(value)
+	case "gt":
+		return t.After(value)
+	case "ge":
+		return !t.Before(value)
+	case "lt":
+		return t.Before(value)
+	case "le":
+		return !t.After(value)
+	}
+	return false
+}
@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"blogpost/clients"
+	"blogpost/genprotos/author"
+	"blogpost/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// graphqlBody is a GraphQL over HTTP request
+type graphqlBody struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQL serves queries and mutations over the gRPC clients, it runs behind AuthMiddleware
+// and answers with the standard data and errors object
+func (h Handler) GraphQL() gin.HandlerFunc {
+	schema, err := h.graphqlSchema()
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		var body graphqlBody
+		if err := c.ShouldBindJSON(&body); err != nil || body.Query == "" {
+			graphqlFailure(c, "the body must be a JSON object with a query")
+			return
+		}
+
+		requestCtx := c.Request.Context()
+		// a document that does not parse is reported by graphql.Do with its location
+		if doc, err := parser.Parse(parser.ParseParams{Source: body.Query}); err == nil {
+			if err := h.checkQueryLimits(schema, doc, body.Variables); err != nil {
+				graphqlFailure(c, err.Error())
+				return
+			}
+			// like the REST write routes, so the reads behind a mutation see what it wrote
+			if isMutation(doc, body.OperationName) {
+				requestCtx = clients.WithoutCoalescing(requestCtx)
+			}
+		}
+
+		user, _ := authUser(c)
+		ctx := context.WithValue(requestCtx, graphqlRequestKey{}, &graphqlRequest{
+			user:     user,
+			authors:  authorBatch(requestCtx, h.authorLoader(c)),
+			articles: h.authorArticlesBatch(requestCtx),
+		})
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  body.Query,
+			VariableValues: body.Variables,
+			OperationName:  body.OperationName,
+			Context:        ctx,
+		})
+		c.JSON(http.StatusOK, result)
+	}
+}
+
+// isMutation reports whether the operation graphql.Do is going to run is a mutation
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (operation.Name != nil && operation.Name.Value == operationName) {
+			return operation.Operation == ast.OperationTypeMutation
+		}
+	}
+	return false
+}
+
+// graphqlFailure rejects a request before execution
+func graphqlFailure(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusBadRequest, graphql.Result{
+		Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(message)},
+	})
+}
+
+// idBatch collects the ids asked for while one level of a query resolves,
+// they are then loaded together, each at most once per request
+type idBatch struct {
+	ctx  context.Context
+	load func(ctx context.Context, ids []string) (map[string]interface{}, error)
+
+	mu      sync.Mutex
+	pending []string
+}
+
+// get returns a thunk, graphql-go runs every thunk of a level after all of them were returned
+func (b *idBatch) get(id string) func() (interface{}, error) {
+	b.mu.Lock()
+	b.pending = append(b.pending, id)
+	b.mu.Unlock()
+
+	return func() (interface{}, error) {
+		b.mu.Lock()
+		pending := b.pending
+		b.pending = nil
+		b.mu.Unlock()
+
+		if len(pending) > 0 {
+			if _, err := b.load(b.ctx, pending); err != nil {
+				return nil, grpcGraphQLError(err)
+			}
+		}
+		loaded, err := b.load(b.ctx, []string{id})
+		if err != nil {
+			return nil, grpcGraphQLError(err)
+		}
+		return loaded[id], nil
+	}
+}
+
+// authorBatch loads the authors of Article.author through the request's authorLoader
+func authorBatch(ctx context.Context, loader *authorLoader) *idBatch {
+	return &idBatch{ctx: ctx, load: func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		authors, err := loader.load(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		loaded := make(map[string]interface{}, len(authors))
+		for id, a := range authors {
+			loaded[id] = a
+		}
+		return loaded, nil
+	}}
+}
+
+// authorArticlesBatch loads the articles of Author.articles. The author service has no call for the articles
+// of several authors, so each author's are fetched once on the same bounded pool of workers as ?expand=author
+func (h Handler) authorArticlesBatch(ctx context.Context) *idBatch {
+	workers := h.Conf.ExpandWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	articles := make(map[string][]models.Article)
+	return &idBatch{ctx: ctx, load: func(ctx context.Context, ids []string) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		missing := make(map[string]bool)
+		for _, id := range ids {
+			if _, ok := articles[id]; !ok {
+				missing[id] = true
+			}
+		}
+
+		var resultMu sync.Mutex
+		err := fetchEach(ctx, workers, missing, func(ctx context.Context, id string) error {
+			res, err := h.grpcClients.Author.GetArticlesByAuthorID(ctx, &author.Id{
+				Id: id,
+			})
+			if err != nil {
+				return err
+			}
+			resultMu.Lock()
+			articles[id] = models.NewArticleListFromAuthorArticles(res.GetArticles())
+			resultMu.Unlock()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		loaded := make(map[string]interface{}, len(ids))
+		for _, id := range ids {
+			loaded[id] = articles[id]
+		}
+		return loaded, nil
+	}}
+}
+
+// checkQueryLimits rejects documents nested deeper than GraphQLMaxDepth or resolving more than
+// GraphQLMaxComplexity fields. The selection of a list field counts once per item it may return,
+// introspection fields are not counted so GraphiQL keeps working
+func (h Handler) checkQueryLimits(schema graphql.Schema, doc *ast.Document, variables map[string]interface{}) error {
+	m := queryMeasure{
+		schema:    schema,
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		visiting:  make(map[string]bool),
+		maxSize:   h.Conf.MaxLimit,
+	}
+	m.defaultSize, _ = strconv.Atoi(h.Conf.DefaultLimit)
+	for _, def := range doc.Definitions {
+		if fragment, ok := def.(*ast.FragmentDefinition); ok {
+			m.fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	maxDepth, maxComplexity := h.Conf.GraphQLMaxDepth, h.Conf.GraphQLMaxComplexity
+	for _, def := range doc.Definitions {
+		operation, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		var root graphql.Type = schema.QueryType()
+		if operation.Operation == ast.OperationTypeMutation {
+			root = schema.MutationType()
+		}
+		depth, complexity := m.selection(operation.SelectionSet, root)
+		if maxDepth > 0 && depth > maxDepth {
+			return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, maxDepth)
+		}
+		if maxComplexity > 0 && complexity > maxComplexity {
+			return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, maxComplexity)
+		}
+	}
+	return nil
+}
+
+// queryMeasure walks a document along the schema's types to tell list fields and the type of their items
+type queryMeasure struct {
+	schema    graphql.Schema
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	visiting  map[string]bool
+	// defaultSize is the length assumed of a list without a limit argument, maxSize caps the limit
+	defaultSize int
+	maxSize     int
+}
+
+func (m queryMeasure) selection(set *ast.SelectionSet, parent graphql.Type) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, selection := range set.Selections {
+		var childDepth, childComplexity int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			// every alias is resolved on its own and counted as such
+			fieldType, list := m.fieldType(parent, s.Name.Value)
+			childDepth, childComplexity = m.selection(s.SelectionSet, fieldType)
+			if list {
+				childComplexity *= m.listSize(s)
+			}
+			childDepth++
+			childComplexity++
+		case *ast.InlineFragment:
+			fragmentType := parent
+			if s.TypeCondition != nil {
+				fragmentType = m.schema.Type(s.TypeCondition.Name.Value)
+			}
+			childDepth, childComplexity = m.selection(s.SelectionSet, fragmentType)
+		case *ast.FragmentSpread:
+			fragment, ok := m.fragments[s.Name.Value]
+			// cycles are left to the validation of graphql.Do
+			if !ok || m.visiting[s.Name.Value] {
+				continue
+			}
+			m.visiting[s.Name.Value] = true
+			childDepth, childComplexity = m.selection(fragment.SelectionSet, m.schema.Type(fragment.TypeCondition.Name.Value))
+			delete(m.visiting, s.Name.Value)
+		}
+
+		if childDepth > depth {
+			depth = childDepth
+		}
+		complexity += childComplexity
+	}
+	return depth, complexity
+}
+
+// fieldType is the named type of a field of parent, list tells whether the field returns a list of it.
+// Unknown fields are left to the validation of graphql.Do
+func (m queryMeasure) fieldType(parent graphql.Type, name string) (t graphql.Type, list bool) {
+	var fields graphql.FieldDefinitionMap
+	switch p := parent.(type) {
+	case *graphql.Object:
+		fields = p.Fields()
+	case *graphql.Interface:
+		fields = p.Fields()
+	}
+	field, ok := fields[name]
+	if !ok {
+		return nil, false
+	}
+
+	t = field.Type
+	if nonNull, ok := t.(*graphql.NonNull); ok {
+		t = nonNull.OfType
+	}
+	if l, ok := t.(*graphql.List); ok {
+		list, t = true, l.OfType
+		if nonNull, ok := t.(*graphql.NonNull); ok {
+			t = nonNull.OfType
+		}
+	}
+	return t, list
+}
+
+// listSize is how many items a list field may return: its limit argument capped like the resolvers do,
+// or the default page size. A limit whose value is not known counts as the cap
+func (m queryMeasure) listSize(field *ast.Field) int {
+	size := m.defaultSize
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		if m.maxSize > 0 {
+			size = m.maxSize
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil {
+				size = n
+			}
+		case *ast.Variable:
+			if n, ok := m.variables[value.Name.Value].(float64); ok {
+				size = int(n)
+			}
+		}
+	}
+	if m.maxSize > 0 && size > m.maxSize {
+		size = m.maxSize
+	}
+	// the selection of an empty list is still checked once
+	if size < 1 {
+		size = 1
+	}
+	return size
+}
+
+// GraphiQL serves an in-browser IDE for /graphql, the Authorization header is set in its headers tab
+func GraphiQL() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiqlPage))
+	}
+}
+
+const graphiqlPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql"></div>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: window.location.pathname });
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(
+      React.createElement(GraphiQL, { fetcher: fetcher, defaultHeaders: '{"Authorization": ""}' })
+    );
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,501 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"blogpost/events"
+	"blogpost/genprotos/article"
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+	"blogpost/models"
+	"blogpost/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+	"google.golang.org/grpc/status"
+)
+
+type graphqlRequestKey struct{}
+
+// graphqlRequest is what resolvers know about the HTTP request they run for
+type graphqlRequest struct {
+	user     *authorization.User
+	authors  *idBatch // Article.author
+	articles *idBatch // Author.articles
+}
+
+func requestOf(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+}
+
+// graphqlArticle is the source of the Article type, author is set when the service already returned it
+type graphqlArticle struct {
+	models.Article
+	author *models.Author
+}
+
+func packedToGraphQL(a models.PackedArticleModel) graphqlArticle {
+	return graphqlArticle{
+		Article: models.Article{
+			ID:        a.ID,
+			Content:   a.Content,
+			AuthorID:  a.Author.ID,
+			CreatedAt: a.CreatedAt,
+			UpdatedAt: a.UpdatedAt,
+		},
+		author: &a.Author,
+	}
+}
+
+func articlesToGraphQL(list []models.Article, author *models.Author) []graphqlArticle {
+	articles := make([]graphqlArticle, 0, len(list))
+	for _, a := range list {
+		articles = append(articles, graphqlArticle{Article: a, author: author})
+	}
+	return articles
+}
+
+// graphqlError carries the gRPC status code or the invalid fields of a failed resolver as extensions
+type graphqlError struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e graphqlError) Error() string {
+	return e.message
+}
+
+func (e graphqlError) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+func grpcGraphQLError(err error) error {
+	st, _ := status.FromError(err)
+	return graphqlError{
+		message:    st.Message(),
+		extensions: map[string]interface{}{"code": st.Code().String()},
+	}
+}
+
+func validationGraphQLError(fields []models.FieldError) error {
+	return graphqlError{
+		message:    "validation failed",
+		extensions: map[string]interface{}{"code": "InvalidArgument", "errors": fields},
+	}
+}
+
+// validateInput trims and validates a request model the way bindBody does for REST bodies
+func validateInput(obj interface{}) error {
+	err := validation.Struct(obj)
+	var errs validator.ValidationErrors
+	if errors.As(err, &errs) {
+		return validationGraphQLError(validation.FieldErrors(errs))
+	}
+	return err
+}
+
+func validateID(id string) error {
+	if fields := validation.Var("id", id, "uuid"); len(fields) > 0 {
+		return validationGraphQLError(fields)
+	}
+	return nil
+}
+
+// graphqlSchema resolves Article, Author and User through the gRPC clients
+func (h Handler) graphqlSchema() (graphql.Schema, error) {
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":        userField(graphql.NewNonNull(graphql.ID), func(u models.User) interface{} { return u.ID }),
+			"username":  userField(graphql.NewNonNull(graphql.String), func(u models.User) interface{} { return u.Username }),
+			"userType":  userField(graphql.NewNonNull(graphql.String), func(u models.User) interface{} { return u.UserType }),
+			"createdAt": userField(graphql.DateTime, func(u models.User) interface{} { return u.CreatedAt }),
+			"updatedAt": userField(graphql.DateTime, func(u models.User) interface{} { return u.UpdatedAt }),
+		},
+	})
+
+	authorType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.Fields{
+			"id":        authorField(graphql.NewNonNull(graphql.ID), func(a models.Author) interface{} { return a.ID }),
+			"fullname":  authorField(graphql.NewNonNull(graphql.String), func(a models.Author) interface{} { return a.Fullname }),
+			"createdAt": authorField(graphql.DateTime, func(a models.Author) interface{} { return a.CreatedAt }),
+			"updatedAt": authorField(graphql.DateTime, func(a models.Author) interface{} { return a.UpdatedAt }),
+		},
+	})
+
+	articleType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Article",
+		Fields: graphql.Fields{
+			"id":        articleField(graphql.NewNonNull(graphql.ID), func(a graphqlArticle) interface{} { return a.ID }),
+			"title":     articleField(graphql.NewNonNull(graphql.String), func(a graphqlArticle) interface{} { return a.Title }),
+			"body":      articleField(graphql.NewNonNull(graphql.String), func(a graphqlArticle) interface{} { return a.Body }),
+			"authorId":  articleField(graphql.NewNonNull(graphql.ID), func(a graphqlArticle) interface{} { return a.AuthorID }),
+			"createdAt": articleField(graphql.DateTime, func(a graphqlArticle) interface{} { return a.CreatedAt }),
+			"updatedAt": articleField(graphql.DateTime, func(a graphqlArticle) interface{} { return a.UpdatedAt }),
+			"author": &graphql.Field{
+				Type: authorType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					a := p.Source.(graphqlArticle)
+					if a.author != nil {
+						return *a.author, nil
+					}
+					return requestOf(p.Context).authors.get(a.AuthorID), nil
+				},
+			},
+		},
+	})
+
+	authorType.AddFieldConfig("articles", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(articleType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			a := p.Source.(models.Author)
+			articles := requestOf(p.Context).articles.get(a.ID)
+			return func() (interface{}, error) {
+				list, err := articles()
+				if err != nil {
+					return nil, err
+				}
+				return articlesToGraphQL(list.([]models.Article), &a), nil
+			}, nil
+		},
+	})
+
+	listArgs := graphql.FieldConfigArgument{
+		"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+		"limit":  &graphql.ArgumentConfig{Type: graphql.Int},
+		"search": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+	}
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return models.NewUser(requestOf(p.Context).user), nil
+				},
+			},
+			"article": &graphql.Field{
+				Type:    articleType,
+				Args:    idArgs,
+				Resolve: h.resolveArticle,
+			},
+			"articles": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(articleType))),
+				Args:    listArgs,
+				Resolve: h.resolveArticles,
+			},
+			"author": &graphql.Field{
+				Type:    authorType,
+				Args:    idArgs,
+				Resolve: h.resolveAuthor,
+			},
+			"authors": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(authorType))),
+				Args:    listArgs,
+				Resolve: h.resolveAuthors,
+			},
+		},
+	})
+
+	nonNullString := &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)}
+	nonNullID := &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createArticle": &graphql.Field{
+				Type:    graphql.NewNonNull(articleType),
+				Args:    graphql.FieldConfigArgument{"authorId": nonNullID, "title": nonNullString, "body": nonNullString},
+				Resolve: h.createArticle,
+			},
+			"updateArticle": &graphql.Field{
+				Type:    graphql.NewNonNull(articleType),
+				Args:    graphql.FieldConfigArgument{"id": nonNullID, "title": nonNullString, "body": nonNullString},
+				Resolve: h.updateArticleMutation,
+			},
+			"deleteArticle": &graphql.Field{
+				Type:    graphql.NewNonNull(articleType),
+				Args:    idArgs,
+				Resolve: h.deleteArticle,
+			},
+			"createAuthor": &graphql.Field{
+				Type:        graphql.NewNonNull(authorType),
+				Description: "the author service does not return the created author, so only fullname is set",
+				Args:        graphql.FieldConfigArgument{"fullname": nonNullString},
+				Resolve:     h.createAuthor,
+			},
+			"updateAuthor": &graphql.Field{
+				Type:    graphql.NewNonNull(authorType),
+				Args:    graphql.FieldConfigArgument{"id": nonNullID, "fullname": nonNullString},
+				Resolve: h.updateAuthorMutation,
+			},
+			"deleteAuthor": &graphql.Field{
+				Type:    graphql.NewNonNull(authorType),
+				Args:    idArgs,
+				Resolve: h.deleteAuthor,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{
+		Query:    query,
+		Mutation: mutation,
+	})
+}
+
+func userField(t graphql.Output, get func(models.User) interface{}) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.User)), nil
+	}}
+}
+
+func authorField(t graphql.Output, get func(models.Author) interface{}) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(models.Author)), nil
+	}}
+}
+
+func articleField(t graphql.Output, get func(graphqlArticle) interface{}) *graphql.Field {
+	return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		return get(p.Source.(graphqlArticle)), nil
+	}}
+}
+
+// listWindow reads offset and limit arguments with the same defaults and cap as the REST lists
+func (h Handler) listWindow(args map[string]interface{}) (offset, limit int, err error) {
+	offset, _ = args["offset"].(int)
+	limit, ok := args["limit"].(int)
+	if !ok {
+		limit, _ = strconv.Atoi(h.Conf.DefaultLimit)
+	}
+	if offset < 0 || limit < 0 {
+		return 0, 0, validationGraphQLError([]models.FieldError{{Field: "offset", Message: "offset and limit must not be negative"}})
+	}
+	if h.Conf.MaxLimit > 0 && limit > h.Conf.MaxLimit {
+		limit = h.Conf.MaxLimit
+	}
+	return offset, limit, nil
+}
+
+func (h Handler) resolveArticle(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	found, err := h.grpcClients.Article.GetArticleByID(p.Context, &article.GetArticleByIdReq{
+		Id: id,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	return packedToGraphQL(models.NewPackedArticle(found)), nil
+}
+
+func (h Handler) resolveArticles(p graphql.ResolveParams) (interface{}, error) {
+	offset, limit, err := h.listWindow(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	search, _ := p.Args["search"].(string)
+
+	articleList, err := h.grpcClients.Article.GetArticleList(p.Context, &article.GetArticleListReq{
+		Offset: int32(offset),
+		Limit:  int32(limit),
+		Search: search,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	return articlesToGraphQL(models.NewArticleList(articleList.GetArticles()), nil), nil
+}
+
+func (h Handler) resolveAuthor(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	found, err := h.grpcClients.Author.GetAuthorByID(p.Context, &author.Id{
+		Id: id,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	return models.NewAuthorFromRes(found), nil
+}
+
+func (h Handler) resolveAuthors(p graphql.ResolveParams) (interface{}, error) {
+	offset, limit, err := h.listWindow(p.Args)
+	if err != nil {
+		return nil, err
+	}
+	search, _ := p.Args["search"].(string)
+
+	authorList, err := h.grpcClients.Author.GetAuthorList(p.Context, &author.GetAuthorListReq{
+		Offset: int64(offset),
+		Limit:  int64(limit),
+		Search: search,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	return models.NewAuthorList(authorList.GetAuthors()), nil
+}
+
+func (h Handler) createArticle(p graphql.ResolveParams) (interface{}, error) {
+	input := models.CreateArticleModel{AuthorID: p.Args["authorId"].(string)}
+	input.Title, _ = p.Args["title"].(string)
+	input.Body, _ = p.Args["body"].(string)
+	if err := validateInput(&input); err != nil {
+		return nil, err
+	}
+
+	created, err := h.grpcClients.Article.AddArticle(p.Context, &article.AddArticleReq{
+		AuthorId: input.AuthorID,
+		Content: &article.AddArticleReq_Post{
+			Title: input.Title,
+			Body:  input.Body,
+		},
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheArticles)
+
+	found, err := h.grpcClients.Article.GetArticleByID(p.Context, &article.GetArticleByIdReq{
+		Id: created.GetId(),
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	packed := models.NewPackedArticle(found)
+	h.publish(events.ArticleCreated, packed.Author.ID, requestOf(p.Context).user.GetId(), packed)
+	return packedToGraphQL(packed), nil
+}
+
+func (h Handler) updateArticleMutation(p graphql.ResolveParams) (interface{}, error) {
+	input := models.UpdateArticleModel{ID: p.Args["id"].(string)}
+	input.Title, _ = p.Args["title"].(string)
+	input.Body, _ = p.Args["body"].(string)
+	if err := validateInput(&input); err != nil {
+		return nil, err
+	}
+
+	updated, err := h.grpcClients.Article.UpdateArticle(p.Context, &article.UpdateArticleReq{
+		Id: input.ID,
+		Content: &article.UpdateArticleReq_Post{
+			Title: input.Title,
+			Body:  input.Body,
+		},
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheArticles)
+
+	packed := models.NewUpdatedArticle(updated)
+	h.publish(events.ArticleUpdated, packed.Author.ID, requestOf(p.Context).user.GetId(), packed)
+	return packedToGraphQL(packed), nil
+}
+
+func (h Handler) deleteArticle(p graphql.ResolveParams) (interface{}, error) {
+	id, _ := p.Args["id"].(string)
+	if err := validateID(id); err != nil {
+		return nil, err
+	}
+
+	deleted, err := h.grpcClients.Article.DeleteArticle(p.Context, &article.DeleteArticleReq{
+		Id: id,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheArticles)
+
+	a := models.NewDeletedArticle(deleted)
+	h.publish(events.ArticleDeleted, a.AuthorID, requestOf(p.Context).user.GetId(), a)
+	return graphqlArticle{Article: models.Article{
+		ID:        a.ID,
+		Content:   a.Content,
+		AuthorID:  a.AuthorID,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+		DeletedAt: a.DeletedAt,
+	}}, nil
+}
+
+func (h Handler) createAuthor(p graphql.ResolveParams) (interface{}, error) {
+	input := models.CreateAuthorModel{}
+	input.Fullname, _ = p.Args["fullname"].(string)
+	if err := validateInput(&input); err != nil {
+		return nil, err
+	}
+
+	_, err := h.grpcClients.Author.AddAuthor(p.Context, &author.CreateAuthorReq{
+		Fullname: input.Fullname,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheAuthors)
+
+	// the author service answers with an empty message, so the new author's id is not known here
+	created := models.Author{Fullname: input.Fullname}
+	h.publishAuthor(p, events.AuthorCreated, created)
+	return created, nil
+}
+
+func (h Handler) updateAuthorMutation(p graphql.ResolveParams) (interface{}, error) {
+	input := models.UpdateAuthorModel{ID: p.Args["id"].(string)}
+	input.Fullname, _ = p.Args["fullname"].(string)
+	if err := validateInput(&input); err != nil {
+		return nil, err
+	}
+
+	_, err := h.grpcClients.Author.UpdateAuthor(p.Context, &author.UpdateAuthorReq{
+		Id:       input.ID,
+		Fullname: input.Fullname,
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheAuthors)
+
+	updated, err := h.resolveAuthor(graphql.ResolveParams{Context: p.Context, Args: map[string]interface{}{"id": input.ID}})
+	if err != nil {
+		return nil, err
+	}
+	h.publishAuthor(p, events.AuthorUpdated, updated)
+	return updated, nil
+}
+
+func (h Handler) deleteAuthor(p graphql.ResolveParams) (interface{}, error) {
+	// DeleteAuthor replies with an empty message, so the author is read before it is gone
+	deleted, err := h.resolveAuthor(p)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = h.grpcClients.Author.DeleteAuthor(p.Context, &author.Id{
+		Id: p.Args["id"].(string),
+	})
+	if err != nil {
+		return nil, grpcGraphQLError(err)
+	}
+	h.invalidate(p.Context, CacheAuthors)
+	h.publishAuthor(p, events.AuthorDeleted, deleted)
+	return deleted, nil
+}
+
+// publishAuthor hands an author a mutation changed to the event stream
+func (h Handler) publishAuthor(p graphql.ResolveParams, eventType string, resolved interface{}) {
+	a := resolved.(models.Author)
+	h.publish(eventType, a.ID, requestOf(p.Context).user.GetId(), a)
+}
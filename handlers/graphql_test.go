@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"blogpost/config"
+	"blogpost/genprotos/author"
+
+	"github.com/graphql-go/graphql/language/parser"
+	"google.golang.org/grpc"
+)
+
+// countingAuthorClient counts the articles lookups per author
+type countingAuthorClient struct {
+	fakeAuthorClient
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (f *countingAuthorClient) GetArticlesByAuthorID(ctx context.Context, in *author.Id, opts ...grpc.CallOption) (*author.GetArticles, error) {
+	f.mu.Lock()
+	f.calls[in.GetId()]++
+	f.mu.Unlock()
+	return f.fakeAuthorClient.GetArticlesByAuthorID(ctx, in, opts...)
+}
+
+func TestGraphQLAuthorArticlesLoadedOncePerAuthor(t *testing.T) {
+	client := &countingAuthorClient{calls: make(map[string]int)}
+	router := newTestRouterWith(client)
+
+	query := `{"query":"{ a: author(id: \"` + testUser.Id + `\") { articles { id } } b: author(id: \"` + testUser.Id + `\") { articles { title } } }"}`
+	w := serve(t, router, http.MethodPost, "/graphql", query)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	var got struct {
+		Data map[string]struct {
+			Articles []map[string]interface{} `json:"articles"`
+		} `json:"data"`
+		Errors []interface{} `json:"errors"`
+	}
+	decodeBody(t, w, &got)
+	if len(got.Errors) > 0 || len(got.Data["a"].Articles) != 1 || len(got.Data["b"].Articles) != 1 {
+		t.Fatalf("unexpected result: %s", w.Body.String())
+	}
+	if calls := client.calls[testUser.Id]; calls != 1 {
+		t.Errorf("GetArticlesByAuthorID called %d times for one author, want 1", calls)
+	}
+}
+
+func TestIsMutation(t *testing.T) {
+	tests := []struct {
+		query     string
+		operation string
+		want      bool
+	}{
+		{query: `{ me { id } }`, want: false},
+		{query: `mutation { deleteAuthor(id: "1") { id } }`, want: true},
+		{query: `query Q { me { id } } mutation M { deleteAuthor(id: "1") { id } }`, operation: "M", want: true},
+		{query: `query Q { me { id } } mutation M { deleteAuthor(id: "1") { id } }`, operation: "Q", want: false},
+	}
+
+	for _, tt := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+		if err != nil {
+			t.Fatalf("parse %q: %v", tt.query, err)
+		}
+		if got := isMutation(doc, tt.operation); got != tt.want {
+			t.Errorf("isMutation(%q, %q) = %v, want %v", tt.query, tt.operation, got, tt.want)
+		}
+	}
+}
+
+func TestCheckQueryLimitsCountsListItems(t *testing.T) {
+	h := Handler{Conf: config.Config{DefaultLimit: "10", MaxLimit: 100}}
+	schema, err := h.graphqlSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		want      int
+	}{
+		{name: "default page", query: `{ articles { id title } }`, want: 21},
+		{name: "limit", query: `{ articles(limit: 50) { id } }`, want: 51},
+		{name: "limit past the maximum", query: `{ articles(limit: 500) { id } }`, want: 101},
+		{name: "limit variable", query: `query($n: Int) { articles(limit: $n) { id } }`, variables: map[string]interface{}{"n": 5.0}, want: 6},
+		{name: "unknown limit", query: `query($n: Int) { articles(limit: $n) { id } }`, want: 101},
+		{name: "nested list", query: `{ authors(limit: 3) { articles { id } } }`, want: 34},
+		{name: "aliases", query: `{ a: authors(limit: 2) { id } b: authors(limit: 2) { id } c: me { id } }`, want: 8},
+		{name: "fragment", query: `{ authors(limit: 2) { ...f } } fragment f on Author { articles { id } }`, want: 23},
+		{name: "single author", query: `{ author(id: "1") { id fullname articles { id } } }`, want: 14},
+		{name: "introspection", query: `{ __schema { types { name } } articles(limit: 1) { id } }`, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.query, err)
+			}
+			h.Conf.GraphQLMaxComplexity = tt.want
+			if err := h.checkQueryLimits(schema, doc, tt.variables); err != nil {
+				t.Errorf("complexity %d: %v", tt.want, err)
+			}
+			h.Conf.GraphQLMaxComplexity = tt.want - 1
+			if err := h.checkQueryLimits(schema, doc, tt.variables); err == nil {
+				t.Errorf("complexity %d accepted, want it above %d", tt.want-1, tt.want-1)
+			}
+		})
+	}
+}
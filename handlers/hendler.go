@@ -1,18 +1,56 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"blogpost/cache"
 	"blogpost/clients"
 	"blogpost/config"
+	"blogpost/events"
+
+	"golang.org/x/sync/singleflight"
 )
 
 type Handler struct {
 	Conf        config.Config
 	grpcClients *clients.GrpcClients
+	cache       cache.Store // nil when caching is off
+	engine      *engineRef
+	refreshing  *singleflight.Group
+	cursorKey   []byte
+	broker      *events.Broker
 }
 
-func NewHandler(conf config.Config, grpcClients *clients.GrpcClients) Handler {
+// engineRef lets handlers send requests through the gateway's own routes, it is filled by SetEngine
+type engineRef struct {
+	http.Handler
+}
+
+// NewHandler fails when no cursor key can be generated, cursors would otherwise be signed with a guessable one
+func NewHandler(conf config.Config, grpcClients *clients.GrpcClients, store cache.Store) (Handler, error) {
+	cursorKey := []byte(conf.CursorSecret)
+	if len(cursorKey) == 0 {
+		// cursors then only survive as long as this process
+		cursorKey = make([]byte, 32)
+		if _, err := rand.Read(cursorKey); err != nil {
+			return Handler{}, fmt.Errorf("generate cursor key: %w", err)
+		}
+	}
+
 	return Handler{
 		Conf:        conf,
 		grpcClients: grpcClients,
-	}
+		cache:       store,
+		engine:      &engineRef{},
+		refreshing:  &singleflight.Group{},
+		cursorKey:   cursorKey,
+		broker:      events.NewBroker(conf.EventsReplaySize),
+	}, nil
+}
+
+// SetEngine must be called once every route is registered
+func (h Handler) SetEngine(engine http.Handler) {
+	h.engine.Handler = engine
 }
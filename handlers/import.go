@@ -0,0 +1,460 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"blogpost/events"
+	"blogpost/genprotos/article"
+	"blogpost/models"
+	"blogpost/response"
+	"blogpost/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/status"
+)
+
+// Import formats, picked by ?format= or the extension of each uploaded file
+const (
+	importJSONL    = "jsonl"
+	importCSV      = "csv"
+	importMarkdown = "markdown"
+)
+
+// maxImportLine bounds one JSON Lines record
+const maxImportLine = 1 << 20
+
+// importRecord is a parsed article on its way to validation and creation
+type importRecord struct {
+	index   int
+	file    string
+	row     int
+	article models.CreateArticleModel
+	err     error // the record could not be parsed
+}
+
+// errImportLimit stops reading once the configured number of records was reached
+var errImportLimit = errors.New("too many records")
+
+// ImportArticles godoc
+// @Summary     Import articles
+// @Description create articles from uploaded JSON Lines, CSV (title, body, author_id columns) or Markdown files with front matter.
+// @Description Each record is validated and reported on its own, records without author_id use the author_id param.
+// @Description The upload may be compressed, IMPORT_MAX_BYTES then limits the decompressed size
+// @Tags        articles
+// @Accept      multipart/form-data
+// @Produce     json
+// @Produce     text/csv
+// @Param       file             formData file   true  "one or more .jsonl, .csv or .md files"
+// @Param       format           query    string false "jsonl, csv or markdown, overrides the file extension"
+// @Param       author_id        query    string false "author of records that do not name one"
+// @Param       dry_run          query    bool   false "validate without creating anything"
+// @Param       report           query    string false "json (default) or csv to download the per-row report"
+// @Param       Content-Encoding header   string false "gzip, br or zstd when the upload is compressed"
+// @Param       Authorization    header   string false "Authorization"
+// @Success     200              {object} models.JSONResponse{data=models.ImportReport}
+// @Failure     400              {object} models.Problem
+// @Failure     413              {object} models.Problem
+// @Failure     415              {object} models.Problem
+// @Router      /v1/article/import [post]
+func (h Handler) ImportArticles(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "dry_run error")
+		return
+	}
+	report := c.DefaultQuery("report", "json")
+	if report != "json" && report != "csv" {
+		response.Error(c, http.StatusBadRequest, "report error")
+		return
+	}
+	format := c.Query("format")
+	if format != "" && format != importJSONL && format != importCSV && format != importMarkdown {
+		response.Error(c, http.StatusBadRequest, "format error")
+		return
+	}
+
+	if h.Conf.ImportMaxBytes > 0 {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.Conf.ImportMaxBytes)
+	}
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		response.Error(c, http.StatusUnsupportedMediaType, "articles must be uploaded as multipart/form-data")
+		return
+	}
+
+	defaultAuthor := c.Query("author_id")
+	records := make(chan importRecord)
+	var (
+		mu      sync.Mutex
+		results []indexedResult
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < h.importWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for record := range records {
+				result := h.importRecord(c, record, defaultAuthor, dryRun)
+				mu.Lock()
+				results = append(results, indexedResult{record.index, result})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	readErr := h.readImport(reader, format, records)
+	close(records)
+	wg.Wait()
+
+	if readErr != nil && len(results) == 0 {
+		readError(c, readErr)
+		return
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].index < results[j].index })
+	out := models.ImportReport{
+		Summary: models.ImportSummary{DryRun: dryRun},
+		Results: make([]models.ImportResult, 0, len(results)),
+	}
+	for _, r := range results {
+		out.Results = append(out.Results, r.ImportResult)
+		out.Summary.Total++
+		switch r.Status {
+		case models.ImportCreated:
+			out.Summary.Created++
+		case models.ImportValid:
+			out.Summary.Valid++
+		case models.ImportInvalid:
+			out.Summary.Invalid++
+		case models.ImportFailed:
+			out.Summary.Failed++
+		}
+	}
+	if readErr != nil {
+		out.Error = "the upload was read only in part: " + readErr.Error()
+	}
+	// a dry run or an import where every record failed leaves the cached lists valid
+	if out.Summary.Created > 0 {
+		h.invalidate(c.Request.Context(), CacheArticles)
+	}
+
+	if report == "csv" {
+		writeImportCSV(c, out)
+		return
+	}
+	response.OK(c, http.StatusOK, "Import | Done", out)
+}
+
+type indexedResult struct {
+	index int
+	models.ImportResult
+}
+
+func (h Handler) importWorkers() int {
+	if h.Conf.ImportWorkers <= 0 {
+		return 1
+	}
+	return h.Conf.ImportWorkers
+}
+
+// importRecord validates one record and creates it unless this is a dry run
+func (h Handler) importRecord(c *gin.Context, record importRecord, defaultAuthor string, dryRun bool) models.ImportResult {
+	result := models.ImportResult{
+		File:  record.file,
+		Row:   record.row,
+		Title: record.article.Title,
+	}
+	if record.err != nil {
+		result.Status = models.ImportInvalid
+		result.Error = record.err.Error()
+		return result
+	}
+
+	if record.article.AuthorID == "" {
+		record.article.AuthorID = defaultAuthor
+	}
+	if err := validation.Struct(&record.article); err != nil {
+		result.Status = models.ImportInvalid
+		var errs validator.ValidationErrors
+		if errors.As(err, &errs) {
+			result.Errors = validation.FieldErrors(errs)
+		} else {
+			result.Error = err.Error()
+		}
+		return result
+	}
+	result.Title = record.article.Title
+
+	if dryRun {
+		result.Status = models.ImportValid
+		return result
+	}
+
+	created, err := h.grpcClients.Article.AddArticle(c.Request.Context(), &article.AddArticleReq{
+		AuthorId: record.article.AuthorID,
+		Content: &article.AddArticleReq_Post{
+			Title: record.article.Title,
+			Body:  record.article.Body,
+		},
+	})
+	if err != nil {
+		st, _ := status.FromError(err)
+		result.Status = models.ImportFailed
+		result.Error = st.Message()
+		return result
+	}
+
+	h.publish(events.ArticleCreated, created.GetAuthorId(), c.GetString("auth_user_id"), models.NewArticle(created))
+
+	result.Status = models.ImportCreated
+	result.ID = created.GetId()
+	return result
+}
+
+// readImport streams every uploaded file into records, at most the configured number of them
+func (h Handler) readImport(reader *multipart.Reader, format string, records chan<- importRecord) error {
+	count := 0
+	emit := func(file string, row int, a models.CreateArticleModel, err error) error {
+		if h.Conf.ImportMaxRecords > 0 && count >= h.Conf.ImportMaxRecords {
+			return fmt.Errorf("%w, at most %d are imported at once", errImportLimit, h.Conf.ImportMaxRecords)
+		}
+		records <- importRecord{index: count, file: file, row: row, article: a, err: err}
+		count++
+		return nil
+	}
+
+	files := 0
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if part.FileName() == "" {
+			part.Close()
+			continue
+		}
+		files++
+
+		name := part.FileName()
+		emitFile := func(row int, a models.CreateArticleModel, err error) error {
+			return emit(name, row, a, err)
+		}
+		fileFormat := format
+		if fileFormat == "" {
+			fileFormat = importFormat(name, part.Header.Get("Content-Type"))
+		}
+
+		switch fileFormat {
+		case importJSONL:
+			err = parseJSONL(part, emitFile)
+		case importCSV:
+			err = parseCSV(part, emitFile)
+		case importMarkdown:
+			err = parseMarkdown(part, emitFile)
+		default:
+			err = emitFile(0, models.CreateArticleModel{}, errors.New("unsupported file type, use .jsonl, .csv or .md"))
+		}
+		part.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if files == 0 {
+		return errors.New("no file was uploaded")
+	}
+	return nil
+}
+
+func importFormat(name, contentType string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".jsonl", ".ndjson":
+		return importJSONL
+	case ".csv":
+		return importCSV
+	case ".md", ".markdown":
+		return importMarkdown
+	}
+	switch {
+	case strings.HasPrefix(contentType, "application/x-ndjson"), strings.HasPrefix(contentType, "application/jsonl"):
+		return importJSONL
+	case strings.HasPrefix(contentType, "text/csv"):
+		return importCSV
+	case strings.HasPrefix(contentType, "text/markdown"):
+		return importMarkdown
+	}
+	return ""
+}
+
+// emitFunc hands a parsed record of the current file on, an error stops the upload
+type emitFunc func(row int, a models.CreateArticleModel, err error) error
+
+// parseJSONL reads one article object per line, blank lines are skipped
+func parseJSONL(r io.Reader, emit emitFunc) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxImportLine)
+	for row := 1; scanner.Scan(); row++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var a models.CreateArticleModel
+		err := json.Unmarshal(line, &a)
+		if err := emit(row, a, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// parseCSV reads a header row naming the title, body and optionally author_id columns, then one article per row
+func parseCSV(r io.Reader, emit emitFunc) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return emit(1, models.CreateArticleModel{}, fmt.Errorf("the header row cannot be read: %v", err))
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["title"]; !ok {
+		return emit(1, models.CreateArticleModel{}, errors.New("the header row has no title column"))
+	}
+	if _, ok := columns["body"]; !ok {
+		return emit(1, models.CreateArticleModel{}, errors.New("the header row has no body column"))
+	}
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			return err
+		}
+
+		var a models.CreateArticleModel
+		if err == nil {
+			a.Title = column(record, "title")
+			a.Body = column(record, "body")
+			a.AuthorID = column(record, "author_id")
+		}
+		if err := emit(row, a, err); err != nil {
+			return err
+		}
+	}
+}
+
+// parseMarkdown reads one article per file, title and author_id come from a front matter block
+// between --- lines, or the title from the first # heading when the front matter has none
+func parseMarkdown(r io.Reader, emit emitFunc) error {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	text := strings.ReplaceAll(string(raw), "\r\n", "\n")
+
+	var a models.CreateArticleModel
+	if strings.HasPrefix(text, "---\n") {
+		end := strings.Index(text[4:], "\n---")
+		if end < 0 {
+			return emit(1, a, errors.New("the front matter is not closed with ---"))
+		}
+		for _, line := range strings.Split(text[4:4+end], "\n") {
+			key, value, ok := cutFrontMatter(line)
+			if !ok {
+				continue
+			}
+			switch key {
+			case "title":
+				a.Title = value
+			case "author_id":
+				a.AuthorID = value
+			}
+		}
+		text = text[4+end+len("\n---"):]
+		if i := strings.IndexByte(text, '\n'); i >= 0 {
+			text = text[i+1:]
+		} else {
+			text = ""
+		}
+	}
+
+	if a.Title == "" {
+		lines := strings.SplitN(strings.TrimLeft(text, "\n"), "\n", 2)
+		if strings.HasPrefix(lines[0], "# ") {
+			a.Title = strings.TrimSpace(lines[0][2:])
+			text = ""
+			if len(lines) == 2 {
+				text = lines[1]
+			}
+		}
+	}
+	a.Body = strings.TrimSpace(text)
+	return emit(1, a, nil)
+}
+
+// cutFrontMatter splits a `key: value` line, quotes around the value are dropped
+func cutFrontMatter(line string) (key, value string, ok bool) {
+	i := strings.IndexByte(line, ':')
+	if i < 0 {
+		return "", "", false
+	}
+	key = strings.ToLower(strings.TrimSpace(line[:i]))
+	value = strings.TrimSpace(line[i+1:])
+	if unquoted, err := strconv.Unquote(value); err == nil {
+		value = unquoted
+	} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		value = value[1 : len(value)-1]
+	}
+	return key, value, true
+}
+
+// writeImportCSV sends the report as a downloadable CSV file
+func writeImportCSV(c *gin.Context, report models.ImportReport) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"file", "row", "status", "id", "title", "error"})
+	for _, r := range report.Results {
+		problems := r.Error
+		for _, e := range r.Errors {
+			if problems != "" {
+				problems += "; "
+			}
+			problems += e.Field + " " + e.Message
+		}
+		w.Write([]string{r.File, strconv.Itoa(r.Row), r.Status, r.ID, r.Title, problems})
+	}
+	w.Flush()
+
+	c.Header("Content-Disposition", `attachment; filename="article-import-report.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
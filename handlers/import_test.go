@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"blogpost/clients"
+	"blogpost/config"
+	"blogpost/genprotos/article"
+	"blogpost/models"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const importAuthor = "2b5f7d4e-3a91-4c55-9f0e-6a1c3b8d2e10"
+
+// fakeArticleImport creates articles through AddArticle, a title of "fail" is refused
+type fakeArticleImport struct {
+	article.ArticleServicesClient
+
+	mu     sync.Mutex
+	titles []string
+}
+
+func (f *fakeArticleImport) AddArticle(ctx context.Context, in *article.AddArticleReq, opts ...grpc.CallOption) (*article.AddArticleRes, error) {
+	title := in.GetContent().GetTitle()
+	if title == "fail" {
+		return nil, status.Error(codes.Internal, "article service failed")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.titles = append(f.titles, title)
+	return &article.AddArticleRes{
+		Id:       "id-" + title,
+		AuthorId: in.GetAuthorId(),
+		Content:  &article.AddArticleRes_Post{Title: title, Body: in.GetContent().GetBody()},
+	}, nil
+}
+
+// importUpload builds a multipart body with one file per name
+func importUpload(t *testing.T, files map[string]string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, content := range files {
+		part, err := form.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		part.Write([]byte(content))
+	}
+	if err := form.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, form.FormDataContentType()
+}
+
+func newImportRouter(t *testing.T, conf config.Config, articles article.ArticleServicesClient) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	h, err := NewHandler(conf, &clients.GrpcClients{Article: articles}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	v1 := router.Group("/v1")
+	v1.Use(Negotiate(), SanitizeResponse())
+	v1.POST("/article/import", DecompressBody(), h.ImportArticles)
+	return router
+}
+
+func TestImportCSVReportWithAcceptCSV(t *testing.T) {
+	router := newImportRouter(t, config.Config{ImportWorkers: 2}, nil)
+	body, contentType := importUpload(t, map[string]string{
+		"articles.csv": "title,body,author_id\nHello,World," + importAuthor + "\n",
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/article/import?dry_run=true&report=csv", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", got)
+	}
+	want := "file,row,status,id,title,error\narticles.csv,2,valid,,Hello,\n"
+	if w.Body.String() != want {
+		t.Errorf("report = %q, want %q", w.Body.String(), want)
+	}
+}
+
+// postImport uploads files to the import route and decodes the JSON report
+func postImport(t *testing.T, router http.Handler, query string, files map[string]string) (*httptest.ResponseRecorder, models.ImportReport) {
+	t.Helper()
+	body, contentType := importUpload(t, files)
+	req := httptest.NewRequest(http.MethodPost, "/v1/article/import"+query, body)
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var got struct {
+		Data models.ImportReport `json:"data"`
+	}
+	if w.Code == http.StatusOK {
+		decodeBody(t, w, &got)
+	}
+	return w, got.Data
+}
+
+// parsedRecord is what a parser hands to emit
+type parsedRecord struct {
+	row     int
+	article models.CreateArticleModel
+	failed  bool
+}
+
+func collect(records *[]parsedRecord) emitFunc {
+	return func(row int, a models.CreateArticleModel, err error) error {
+		*records = append(*records, parsedRecord{row: row, article: a, failed: err != nil})
+		return nil
+	}
+}
+
+func newImportArticle(title, body, authorID string) models.CreateArticleModel {
+	return models.CreateArticleModel{Content: models.Content{Title: title, Body: body}, AuthorID: authorID}
+}
+
+func TestParseJSONL(t *testing.T) {
+	input := `{"title":"One","body":"First","author_id":"a"}
+
+{"title":"Two","body":"Second"}
+not json
+`
+	var got []parsedRecord
+	if err := parseJSONL(strings.NewReader(input), collect(&got)); err != nil {
+		t.Fatal(err)
+	}
+	want := []parsedRecord{
+		{row: 1, article: newImportArticle("One", "First", "a")},
+		{row: 3, article: newImportArticle("Two", "Second", "")},
+		{row: 4, failed: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseJSONL() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []parsedRecord
+	}{
+		{
+			name:  "columns in any order",
+			input: "\ufeffAuthor_ID, Body ,Title\na,First,One\n,\"Two, lines\nof body\",Two\n",
+			want: []parsedRecord{
+				{row: 2, article: newImportArticle("One", "First", "a")},
+				{row: 3, article: newImportArticle("Two", "Two, lines\nof body", "")},
+			},
+		},
+		{
+			name:  "short row",
+			input: "title,body,author_id\nOne\n",
+			want:  []parsedRecord{{row: 2, article: newImportArticle("One", "", "")}},
+		},
+		{
+			name:  "broken quote",
+			input: "title,body\n\"One,First\n",
+			want:  []parsedRecord{{row: 2, failed: true}},
+		},
+		{
+			name:  "no title column",
+			input: "name,body\nOne,First\n",
+			want:  []parsedRecord{{row: 1, failed: true}},
+		},
+		{
+			name:  "no body column",
+			input: "title\nOne\n",
+			want:  []parsedRecord{{row: 1, failed: true}},
+		},
+		{
+			name:  "empty file",
+			input: "",
+			want:  []parsedRecord{{row: 1, failed: true}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []parsedRecord
+			if err := parseCSV(strings.NewReader(tt.input), collect(&got)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCSV() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  parsedRecord
+	}{
+		{
+			name:  "front matter",
+			input: "---\ntitle: \"One: the start\"\nauthor_id: 'a'\ntags: x\n---\nFirst\n",
+			want:  parsedRecord{row: 1, article: newImportArticle("One: the start", "First", "a")},
+		},
+		{
+			name:  "heading",
+			input: "\r\n# One\r\n\r\nFirst\r\n",
+			want:  parsedRecord{row: 1, article: newImportArticle("One", "First", "")},
+		},
+		{
+			name:  "front matter without title",
+			input: "---\nauthor_id: a\n---\n# One\nFirst",
+			want:  parsedRecord{row: 1, article: newImportArticle("One", "First", "a")},
+		},
+		{
+			name:  "unclosed front matter",
+			input: "---\ntitle: One\nFirst\n",
+			want:  parsedRecord{row: 1, failed: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []parsedRecord
+			if err := parseMarkdown(strings.NewReader(tt.input), collect(&got)); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != 1 || !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("parseMarkdown() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParserStopsWhenEmitFails(t *testing.T) {
+	stop := errors.New("stop")
+	calls := 0
+	emit := func(row int, a models.CreateArticleModel, err error) error {
+		calls++
+		return stop
+	}
+	if err := parseJSONL(strings.NewReader("{}\n{}\n"), emit); err != stop || calls != 1 {
+		t.Errorf("parseJSONL() = %v after %d records, want stop after 1", err, calls)
+	}
+	calls = 0
+	if err := parseCSV(strings.NewReader("title,body\na,b\nc,d\n"), emit); err != stop || calls != 1 {
+		t.Errorf("parseCSV() = %v after %d records, want stop after 1", err, calls)
+	}
+}
+
+func TestImportFormat(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		want        string
+	}{
+		{name: "posts.jsonl", want: importJSONL},
+		{name: "posts.NDJSON", want: importJSONL},
+		{name: "posts.csv", contentType: "application/x-ndjson", want: importCSV},
+		{name: "post.md", want: importMarkdown},
+		{name: "post.markdown", want: importMarkdown},
+		{name: "posts", contentType: "application/x-ndjson", want: importJSONL},
+		{name: "posts", contentType: "text/csv; charset=utf-8", want: importCSV},
+		{name: "post", contentType: "text/markdown", want: importMarkdown},
+		{name: "posts.json", contentType: "application/json", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := importFormat(tt.name, tt.contentType); got != tt.want {
+			t.Errorf("importFormat(%q, %q) = %q, want %q", tt.name, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestImportReportsEveryRow(t *testing.T) {
+	articles := &fakeArticleImport{}
+	router := newImportRouter(t, config.Config{ImportWorkers: 1}, articles)
+
+	w, report := postImport(t, router, "?author_id="+importAuthor, map[string]string{
+		"posts.ndjson": `{"title":"One","body":"First"}
+{"title":"x","body":"Too short a title"}
+{"title":"fail","body":"Refused by the service"}
+{"title":"Two","body":"Second","author_id":"not-a-uuid"}
+{"title":
+`,
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	wantSummary := models.ImportSummary{Total: 5, Created: 1, Invalid: 3, Failed: 1}
+	if report.Summary != wantSummary {
+		t.Errorf("summary = %+v, want %+v", report.Summary, wantSummary)
+	}
+	wantStatus := []string{models.ImportCreated, models.ImportInvalid, models.ImportFailed, models.ImportInvalid, models.ImportInvalid}
+	if len(report.Results) != len(wantStatus) {
+		t.Fatalf("got %d results, want %d: %s", len(report.Results), len(wantStatus), w.Body.String())
+	}
+	for i, r := range report.Results {
+		if r.File != "posts.ndjson" || r.Row != i+1 || r.Status != wantStatus[i] {
+			t.Errorf("result %d = %+v, want posts.ndjson row %d %s", i, r, i+1, wantStatus[i])
+		}
+	}
+	if report.Results[0].ID != "id-One" {
+		t.Errorf("created record has id %q, want id-One", report.Results[0].ID)
+	}
+	if errs := report.Results[1].Errors; len(errs) != 1 || errs[0].Field != "title" {
+		t.Errorf("short title reported as %+v, want a title error", errs)
+	}
+	if report.Results[2].Error != "article service failed" {
+		t.Errorf("refused record reported as %q", report.Results[2].Error)
+	}
+	if errs := report.Results[3].Errors; len(errs) != 1 || errs[0].Field != "author_id" {
+		t.Errorf("bad author_id reported as %+v, want an author_id error", errs)
+	}
+	if report.Results[4].Error == "" {
+		t.Errorf("unparsable line reported without an error")
+	}
+}
+
+func TestImportDryRunCreatesNothing(t *testing.T) {
+	articles := &fakeArticleImport{}
+	router := newImportRouter(t, config.Config{ImportWorkers: 2}, articles)
+
+	w, report := postImport(t, router, "?dry_run=true", map[string]string{
+		"posts.csv": "title,body,author_id\nOne,First," + importAuthor + "\nTwo,,\n",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	wantSummary := models.ImportSummary{DryRun: true, Total: 2, Valid: 1, Invalid: 1}
+	if report.Summary != wantSummary {
+		t.Errorf("summary = %+v, want %+v", report.Summary, wantSummary)
+	}
+	if len(articles.titles) != 0 {
+		t.Errorf("a dry run created %v", articles.titles)
+	}
+}
+
+func TestImportWorkersKeepTheOrder(t *testing.T) {
+	articles := &fakeArticleImport{}
+	router := newImportRouter(t, config.Config{ImportWorkers: 4}, articles)
+
+	var upload strings.Builder
+	upload.WriteString("title,body\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&upload, "Post %d,Body %d\n", i, i)
+	}
+	w, report := postImport(t, router, "?author_id="+importAuthor, map[string]string{"posts.csv": upload.String()})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if report.Summary.Created != 50 || len(articles.titles) != 50 {
+		t.Fatalf("created %d records through %d calls, want 50", report.Summary.Created, len(articles.titles))
+	}
+	for i, r := range report.Results {
+		if want := fmt.Sprintf("Post %d", i); r.Row != i+2 || r.Title != want || r.ID != "id-"+want {
+			t.Errorf("result %d = %+v, want row %d %s", i, r, i+2, want)
+		}
+	}
+}
+
+func TestImportStopsAtMaxRecords(t *testing.T) {
+	router := newImportRouter(t, config.Config{ImportMaxRecords: 2}, nil)
+
+	w, report := postImport(t, router, "?dry_run=true&author_id="+importAuthor, map[string]string{
+		"posts.csv": "title,body\nOne,First\nTwo,Second\nThree,Third\n",
+	})
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	if report.Summary.Total != 2 || !strings.Contains(report.Error, "at most 2") {
+		t.Errorf("report = %+v, want 2 records and the limit named", report)
+	}
+}
+
+func TestImportCompressedUploadOverTheLimit(t *testing.T) {
+	const limit = 64 << 10
+	router := newImportRouter(t, config.Config{ImportMaxBytes: limit}, nil)
+
+	// the limit counts decompressed bytes, this upload only passes it once decoded
+	body, contentType := importUpload(t, map[string]string{
+		"post.md": "# Big\n\n" + strings.Repeat("a", 16*limit),
+	})
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write(body.Bytes())
+	zw.Close()
+	if compressed.Len() >= limit {
+		t.Fatalf("compressed upload is %d bytes, want it under the limit", compressed.Len())
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/article/import?dry_run=true", &compressed)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Content-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want 413: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"blogpost/genprotos/article"
+	"blogpost/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseOffsetLimit reads offset and limit query params falling back to the configured defaults,
+// limit is capped at the configured maximum
+func (h Handler) parseOffsetLimit(c *gin.Context) (offset, limit int, err error) {
+	offset, err = strconv.Atoi(c.DefaultQuery("offset", h.Conf.DefaultOffset))
+	if err != nil || offset < 0 {
+		return 0, 0, errors.New("offset error")
+	}
+	limit, err = strconv.Atoi(c.DefaultQuery("limit", h.Conf.DefaultLimit))
+	if err != nil || limit < 0 {
+		return 0, 0, errors.New("limit error")
+	}
+	if h.Conf.MaxLimit > 0 && limit > h.Conf.MaxLimit {
+		limit = h.Conf.MaxLimit
+	}
+	return offset, limit, nil
+}
+
+// sortArticles sorts in place by created_at, updated_at or title, an empty field keeps the service order
+func sortArticles(list []models.Article, field, order string) error {
+	var less func(a, b models.Article) bool
+	switch field {
+	case "":
+		return nil
+	case "created_at":
+		less = func(a, b models.Article) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	case "updated_at":
+		less = func(a, b models.Article) bool { return updatedAt(a).Before(updatedAt(b)) }
+	case "title":
+		less = func(a, b models.Article) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) }
+	default:
+		return errors.New("sort error")
+	}
+
+	switch order {
+	case "", "asc":
+	case "desc":
+		asc := less
+		less = func(a, b models.Article) bool { return asc(b, a) }
+	default:
+		return errors.New("order error")
+	}
+
+	sort.SliceStable(list, func(i, j int) bool { return less(list[i], list[j]) })
+	return nil
+}
+
+// updatedAt treats a never updated article as updated when it was created
+func updatedAt(a models.Article) time.Time {
+	if a.UpdatedAt != nil {
+		return *a.UpdatedAt
+	}
+	return a.CreatedAt
+}
+
+// pageArticles cuts a window of limit articles starting at offset
+func pageArticles(list []models.Article, offset, limit int) []models.Article {
+	if offset >= len(list) {
+		return []models.Article{}
+	}
+	end := offset + limit
+	if end > len(list) {
+		end = len(list)
+	}
+	return list[offset:end]
+}
+
+// articleQuery is how GET /v1/article narrows and orders the list beyond the article service's search.
+// The parts GetArticleListReq carries are sent to the service, the gateway applies the whole query to the
+// articles it read when the service is not known to honour them or a clause has no field there
+type articleQuery struct {
+	sort    string
+	order   string
+	clauses []filterClause
+}
+
+// dateParams are the shorthands for created_at clauses, in the order their clauses are added
+var dateParams = []struct {
+	param string
+	op    string
+}{
+	{param: "created_after", op: "gt"},
+	{param: "created_before", op: "lt"},
+}
+
+// parseArticleQuery reads sort, order, author_id, created_after, created_before and filter,
+// the dedicated params are shorthands for clauses of the filter expression
+func parseArticleQuery(c *gin.Context) (articleQuery, error) {
+	q := articleQuery{sort: c.Query("sort"), order: c.Query("order")}
+	// sorting nothing only validates the params
+	if err := sortArticles(nil, q.sort, q.order); err != nil {
+		return q, err
+	}
+
+	if authorID := c.Query("author_id"); authorID != "" {
+		q.clauses = append(q.clauses, filterClause{field: "author_id", op: "eq", value: authorID})
+	}
+	for _, date := range dateParams {
+		value := c.Query(date.param)
+		if value == "" {
+			continue
+		}
+		t, err := parseFilterTime(value)
+		if err != nil {
+			return q, fmt.Errorf("%s error: %v", date.param, err)
+		}
+		q.clauses = append(q.clauses, filterClause{field: "created_at", op: date.op, value: value, time: t})
+	}
+
+	clauses, err := parseFilter(c.Query("filter"))
+	if err != nil {
+		return q, err
+	}
+	q.clauses = append(q.clauses, clauses...)
+	return q, nil
+}
+
+// empty reports whether the query adds nothing to search
+func (q articleQuery) empty() bool {
+	return q.sort == "" && len(q.clauses) == 0
+}
+
+// listRequest is the GetArticleListReq asking the article service for q, complete is false when a clause
+// has no field in it: a condition other than author_id eq, created_at gt and created_at lt, or one of
+// them given twice
+func (q articleQuery) listRequest(search string) (req *article.GetArticleListReq, complete bool) {
+	req = &article.GetArticleListReq{Search: search, SortBy: q.sort, Order: q.order}
+	complete = true
+	for _, clause := range q.clauses {
+		var field *string
+		switch {
+		case clause.field == "author_id" && clause.op == "eq":
+			field = &req.AuthorId
+		case clause.field == "created_at" && clause.op == "gt":
+			field = &req.CreatedAfter
+		case clause.field == "created_at" && clause.op == "lt":
+			field = &req.CreatedBefore
+		}
+		if field == nil || *field != "" {
+			complete = false
+			continue
+		}
+		*field = clause.value
+		if clause.field == "created_at" {
+			*field = clause.time.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return req, complete
+}
+
+// authorScope is the author every matching article must belong to, if the query names one
+func (q articleQuery) authorScope() (string, bool) {
+	for _, clause := range q.clauses {
+		if clause.field == "author_id" && clause.op == "eq" {
+			return clause.value, true
+		}
+	}
+	return "", false
+}
+
+// apply filters and sorts list in place and returns the matching articles
+func (q articleQuery) apply(list []models.Article) []models.Article {
+	matched := list[:0]
+	for _, a := range list {
+		if q.match(a) {
+			matched = append(matched, a)
+		}
+	}
+	sortArticles(matched, q.sort, q.order)
+	return matched
+}
+
+// matching filters list without sorting it, as the records of an export
+func (q articleQuery) matching(list []models.Article) []interface{} {
+	records := make([]interface{}, 0, len(list))
+	for _, a := range list {
+		if q.match(a) {
+			records = append(records, a)
+		}
+	}
+	return records
+}
+
+func (q articleQuery) match(a models.Article) bool {
+	for _, clause := range q.clauses {
+		if !clause.match(a) {
+			return false
+		}
+	}
+	return true
+}
+
+// scanArticles reads every article matching query page by page, setting its Offset and Limit, and fails
+// once more than the configured scan maximum match
+func (h Handler) scanArticles(ctx context.Context, query *article.GetArticleListReq) ([]models.Article, error) {
+	batch := h.Conf.MaxLimit
+	if batch <= 0 {
+		batch = 100
+	}
+
+	var list []models.Article
+	for {
+		limit := batch
+		// one article past the maximum tells there are too many
+		if max := h.Conf.ListScanMax; max > 0 && max+1-len(list) < limit {
+			limit = max + 1 - len(list)
+		}
+		query.Offset = int32(len(list))
+		query.Limit = int32(limit)
+		res, err := h.grpcClients.Article.GetArticleList(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, models.NewArticleList(res.GetArticles())...)
+
+		if h.Conf.ListScanMax > 0 && len(list) > h.Conf.ListScanMax {
+			return nil, errTooManyArticles
+		}
+		if len(res.GetArticles()) < limit {
+			return list, nil
+		}
+	}
+}
+
+var errTooManyArticles = errors.New("too many articles to sort or filter, narrow the list with search or author_id")
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"blogpost/clients"
+	"blogpost/config"
+	"blogpost/genprotos/article"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// fakeArticleList serves count articles through GetArticleList and records the requests
+type fakeArticleList struct {
+	article.ArticleServicesClient
+	count    int
+	requests []*article.GetArticleListReq
+}
+
+func (f *fakeArticleList) GetArticleList(ctx context.Context, in *article.GetArticleListReq, opts ...grpc.CallOption) (*article.GetArticleListRes, error) {
+	f.requests = append(f.requests, &article.GetArticleListReq{
+		Offset:        in.GetOffset(),
+		Limit:         in.GetLimit(),
+		Search:        in.GetSearch(),
+		SortBy:        in.GetSortBy(),
+		Order:         in.GetOrder(),
+		AuthorId:      in.GetAuthorId(),
+		CreatedAfter:  in.GetCreatedAfter(),
+		CreatedBefore: in.GetCreatedBefore(),
+	})
+	res := &article.GetArticleListRes{}
+	for i := int(in.GetOffset()); i < f.count && i < int(in.GetOffset()+in.GetLimit()); i++ {
+		res.Articles = append(res.Articles, &article.AddArticleRes{Id: fmt.Sprint(i), CreatedAt: "2026-01-02T03:04:05Z"})
+	}
+	return res, nil
+}
+
+func TestParseArticleQueryKeepsParamOrder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	target := "/v1/article?filter=title+contains+go&created_before=2026-02-01&author_id=a&created_after=2026-01-01"
+	for i := 0; i < 20; i++ {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest("GET", target, nil)
+		q, err := parseArticleQuery(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, clause := range q.clauses {
+			got = append(got, clause.field+" "+clause.op)
+		}
+		want := "[author_id eq created_at gt created_at lt title contains]"
+		if fmt.Sprint(got) != want {
+			t.Fatalf("clauses = %v, want %s", got, want)
+		}
+	}
+}
+
+func TestListRequest(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name     string
+		query    string
+		want     *article.GetArticleListReq
+		complete bool
+	}{
+		{
+			name:     "sort and dedicated params",
+			query:    "sort=title&order=desc&author_id=a&created_after=2026-01-01&created_before=2026-02-01T10:00:00%2B02:00",
+			want:     &article.GetArticleListReq{Search: "go", SortBy: "title", Order: "desc", AuthorId: "a", CreatedAfter: "2026-01-01T00:00:00Z", CreatedBefore: "2026-02-01T08:00:00Z"},
+			complete: true,
+		},
+		{
+			name:     "filter clauses with a field",
+			query:    "filter=author_id+eq+a+and+created_at+gt+2026-01-01",
+			want:     &article.GetArticleListReq{Search: "go", AuthorId: "a", CreatedAfter: "2026-01-01T00:00:00Z"},
+			complete: true,
+		},
+		{
+			name:  "clause without a field",
+			query: "sort=created_at&filter=title+contains+x",
+			want:  &article.GetArticleListReq{Search: "go", SortBy: "created_at"},
+		},
+		{
+			name:  "field given twice",
+			query: "created_after=2026-01-01&filter=created_at+gt+2026-03-01",
+			want:  &article.GetArticleListReq{Search: "go", CreatedAfter: "2026-01-01T00:00:00Z"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest("GET", "/v1/article?"+tt.query, nil)
+			q, err := parseArticleQuery(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, complete := q.listRequest("go")
+			if complete != tt.complete || got.String() != tt.want.String() {
+				t.Errorf("listRequest() = %v, %v, want %v, %v", got, complete, tt.want, tt.complete)
+			}
+		})
+	}
+}
+
+func TestScanArticles(t *testing.T) {
+	tests := []struct {
+		name     string
+		count    int
+		max      int
+		want     int
+		tooMany  bool
+		requests int
+	}{
+		{name: "one page", count: 3, max: 10, want: 3, requests: 1},
+		{name: "exactly the maximum", count: 10, max: 10, want: 10, requests: 3},
+		{name: "one past the maximum", count: 11, max: 10, tooMany: true, requests: 3},
+		{name: "no maximum", count: 25, want: 25, requests: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeArticleList{count: tt.count}
+			h := Handler{
+				Conf:        config.Config{MaxLimit: 5, ListScanMax: tt.max},
+				grpcClients: &clients.GrpcClients{Article: service},
+			}
+			list, err := h.scanArticles(context.Background(), &article.GetArticleListReq{SortBy: "title"})
+			if tt.tooMany {
+				if err != errTooManyArticles {
+					t.Fatalf("scanArticles() error = %v, want errTooManyArticles", err)
+				}
+			} else if err != nil || len(list) != tt.want {
+				t.Fatalf("scanArticles() = %d articles, %v, want %d", len(list), err, tt.want)
+			}
+			if len(service.requests) != tt.requests {
+				t.Errorf("%d requests, want %d", len(service.requests), tt.requests)
+			}
+			for _, req := range service.requests {
+				if req.GetSortBy() != "title" {
+					t.Errorf("request %v lost the sort", req)
+				}
+			}
+		})
+	}
+}
+
+func TestArticleListPagedByTheService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, supported := range []bool{false, true} {
+		service := &fakeArticleList{count: 30}
+		h, err := NewHandler(config.Config{DefaultOffset: "0", DefaultLimit: "10", MaxLimit: 10, ListScanMax: 100, ArticleListQueries: supported},
+			&clients.GrpcClients{Article: service}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router := gin.New()
+		router.GET("/v1/article", h.GetArticleList)
+		w := serve(t, router, "GET", "/v1/article?search=go&sort=title&author_id=a&offset=10&limit=5", "")
+		if w.Code != 200 {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+
+		if supported {
+			if len(service.requests) != 1 || service.requests[0].GetOffset() != 10 || service.requests[0].GetLimit() != 6 || service.requests[0].GetAuthorId() != "a" {
+				t.Errorf("requests = %v, want one page of the sorted and filtered list", service.requests)
+			}
+		} else if len(service.requests) != 4 {
+			t.Errorf("%d requests, want the gateway to scan the list", len(service.requests))
+		}
+	}
+}
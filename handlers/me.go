@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"net/http"
+
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetMe godoc
+// @Summary     Current user
+// @Description get the user the token belongs to
+// @Tags        me
+// @Accept      json
+// @Produce     json
+// @Param       fields        query    string false "comma separated fields to return, e.g. id,username"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=models.User}
+// @Failure     400           {object} models.Problem
+// @Failure     401           {object} models.Problem
+// @Router      /v1/me [get]
+func (h Handler) GetMe(c *gin.Context) {
+	user, ok := authUser(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+
+	response.OK(c, http.StatusOK, "OK", models.NewUser(user))
+}
+
+// ChangeMyPassword godoc
+// @Summary     Change password
+// @Description change the password of the current user
+// @Tags        me
+// @Accept      json
+// @Produce     json
+// @Param       password      body     models.ChangePasswordModel true  "password body"
+// @Param       Authorization header   string                     false "Authorization"
+// @Success     200           {object} models.JSONResponse
+// @Failure     400           {object} models.Problem
+// @Failure     401           {object} models.Problem
+// @Failure     422           {object} models.Problem
+// @Router      /v1/me/password [put]
+func (h Handler) ChangeMyPassword(c *gin.Context) {
+	var body models.ChangePasswordModel
+	if !bindBody(c, &body) {
+		return
+	}
+
+	user, ok := authUser(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+
+	// the authorization service has no dedicated check, so a successful login proves the old password
+	_, err := h.grpcClients.Authorization.Login(c.Request.Context(), &authorization.LoginRequest{
+		Username: user.Username,
+		Password: body.OldPassword,
+	})
+	if err != nil {
+		response.Error(c, http.StatusUnauthorized, "old password is incorrect")
+		return
+	}
+
+	_, err = h.grpcClients.Authorization.UpdateUser(c.Request.Context(), &authorization.UpdateUserRequest{
+		Id:       user.Id,
+		Password: body.NewPassword,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	response.OK(c, http.StatusOK, "Password | Updated", nil)
+}
+
+// GetMyArticles godoc
+// @Summary     Current user's articles
+// @Description get articles written by the current user. A user writes as the author with the same ID as their user,
+// @Description a user without such an author gets 404
+// @Tags        me
+// @Accept      json
+// @Produce     json
+// @Param       expand        query    string false "author, returns models.PackedArticleModel items"
+// @Param       format        query    string false "raw (default) or html to add the rendered body, excerpt, word count and reading time"
+// @Param       fields        query    string false "comma separated fields to return, e.g. id,title,author.fullname"
+// @Param       Authorization header   string false "Authorization"
+// @Success     200           {object} models.JSONResponse{data=[]models.Article}
+// @Failure     400           {object} models.Problem
+// @Failure     401           {object} models.Problem
+// @Failure     404           {object} models.Problem
+// @Router      /v1/me/articles [get]
+func (h Handler) GetMyArticles(c *gin.Context) {
+	user, ok := authUser(c)
+	if !ok {
+		response.Error(c, http.StatusUnauthorized, "invalid or missing token")
+		return
+	}
+
+	// nothing in the services links a user to an author, so a user writes as the author sharing their ID.
+	// The author is looked up first so a user without one is told instead of getting an empty list
+	_, err := h.grpcClients.Author.GetAuthorByID(c.Request.Context(), &author.Id{
+		Id: user.Id,
+	})
+	if status.Code(err) == codes.NotFound {
+		response.Error(c, http.StatusNotFound, "the current user has no author")
+		return
+	}
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	articles, err := h.grpcClients.Author.GetArticlesByAuthorID(c.Request.Context(), &author.Id{
+		Id: user.Id,
+	})
+	if err != nil {
+		response.GRPCError(c, err)
+		return
+	}
+
+	data, ok := h.expandArticles(c, models.NewArticleListFromAuthorArticles(articles.GetArticles()))
+	if !ok {
+		return
+	}
+	response.OK(c, http.StatusOK, "OK", data)
+}
+
+// authUser returns the user AuthMiddleware stored in the context
+func authUser(c *gin.Context) (*authorization.User, bool) {
+	v, exists := c.Get("auth_user")
+	if !exists {
+		return nil, false
+	}
+	user, ok := v.(*authorization.User)
+	return user, ok && user != nil
+}
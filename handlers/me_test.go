@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserRoutesNeverEmitSensitiveKeys(t *testing.T) {
+	router := newTestRouter()
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+	}{
+		{name: "me", method: http.MethodGet, target: "/v1/me", status: http.StatusOK},
+		{name: "me with fields", method: http.MethodGet, target: "/v1/me?fields=id,username", status: http.StatusOK},
+		{name: "me articles", method: http.MethodGet, target: "/v1/me/articles", status: http.StatusOK},
+		{
+			name:   "change password",
+			method: http.MethodPut,
+			target: "/v1/me/password",
+			body:   `{"old_password":"` + testPassword + `","new_password":"n3w-passw0rd"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "change password with a wrong old password",
+			method: http.MethodPut,
+			target: "/v1/me/password",
+			body:   `{"old_password":"wrong-password","new_password":"n3w-passw0rd"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "login",
+			method: http.MethodPost,
+			target: "/v1/login",
+			body:   `{"username":"alice","password":"` + testPassword + `"}`,
+			status: http.StatusCreated,
+		},
+		{
+			name:   "login rejected",
+			method: http.MethodPost,
+			target: "/v1/login",
+			body:   `{"username":"alice","password":"wrong-password"}`,
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "graphql me",
+			method: http.MethodPost,
+			target: "/graphql",
+			body:   `{"query":"{ me { id username userType createdAt } }"}`,
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, router, tt.method, tt.target, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			assertNoSensitiveKeys(t, w.Body.Bytes())
+		})
+	}
+}
+
+func TestGetMeReturnsTheUser(t *testing.T) {
+	w := serve(t, newTestRouter(), http.MethodGet, "/v1/me", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	var got struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	decodeBody(t, w, &got)
+	if got.Data["id"] != testUser.Id || got.Data["username"] != testUser.Username {
+		t.Errorf("data = %v, want the user %s", got.Data, testUser.Username)
+	}
+	if got.Data["created_at"] != testUser.CreatedAt {
+		t.Errorf("created_at = %v, want %s", got.Data["created_at"], testUser.CreatedAt)
+	}
+	if updatedAt, ok := got.Data["updated_at"]; !ok || updatedAt != nil {
+		t.Errorf("updated_at = %v, want null for an empty timestamp", updatedAt)
+	}
+}
+
+func TestGetMeRequiresToken(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+	w := httptest.NewRecorder()
+	newTestRouter().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
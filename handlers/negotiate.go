@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"strings"
+
+	"blogpost/codec"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/proto"
+)
+
+// responseTypes are the media types a JSON document response can be written in, the gateway's preference first
+var responseTypes = []string{codec.JSON, codec.XML, codec.MsgPack, codec.Protobuf}
+
+// mediaTypesKey is the context key of the response types the client accepts, most preferred first
+const mediaTypesKey = "media_types"
+
+// Negotiate rewrites JSON document responses in the media type the Accept header prefers: XML, MessagePack,
+// or protobuf when the handler offered the generated message through response.SetProto and the request
+// selects no ?fields= and the message holds nothing SanitizeResponse would remove. Responses that are
+// no JSON document, such as exports and CSV reports, are left alone whatever the Accept header, so every
+// request reaches its handler and only a JSON document the client cannot read is answered with 406.
+// It has to run outside SanitizeResponse so the scrubbed document is the one converted. Entity tags get
+// a suffix for every representation but the JSON document of the whole resource
+func Negotiate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept")
+		accepted := codec.Negotiate(c.GetHeader("Accept"), responseTypes...)
+		c.Set(mediaTypesKey, accepted)
+
+		suffix := representationSuffix(accepted, c.Query("fields"))
+		unwrapConditionals(c.Request, suffix, false, isRepresentationSuffix)
+		if suffix != "" {
+			tagged := &etagWriter{ResponseWriter: c.Writer, suffix: suffix}
+			c.Writer = tagged
+			defer func() {
+				tagged.tag()
+				c.Writer = tagged.ResponseWriter
+			}()
+		}
+
+		if len(accepted) > 0 && accepted[0] == codec.JSON {
+			c.Next()
+			return
+		}
+
+		w := newBufferedWriter(c.Writer)
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		body := w.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+		mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+		problem := mediaType == response.ProblemContentType
+
+		for _, accept := range accepted {
+			if accept == codec.JSON {
+				w.ResponseWriter.Write(body)
+				return
+			}
+			out, contentType, ok := encodeResponse(c, accept, body, problem, w.Status())
+			if !ok {
+				continue
+			}
+			w.Header().Set("Content-Type", contentType)
+			w.ResponseWriter.Write(out)
+			return
+		}
+
+		if problem {
+			// a problem the client cannot read is still better than none
+			w.ResponseWriter.Write(body)
+			return
+		}
+		notAcceptable(c)
+	}
+}
+
+// encodeResponse converts a JSON document to another mediaType, ok is false when it cannot be written that way
+func encodeResponse(c *gin.Context, mediaType string, body []byte, problem bool, status int) ([]byte, string, bool) {
+	switch mediaType {
+	case codec.XML:
+		root, contentType := "response", "application/xml; charset=utf-8"
+		if problem {
+			root, contentType = "problem", "application/problem+xml; charset=utf-8"
+		}
+		out, err := codec.JSONToXML(body, root)
+		return out, contentType, err == nil
+	case codec.MsgPack:
+		out, err := codec.JSONToMsgPack(body)
+		return out, codec.MsgPack, err == nil
+	case codec.Protobuf:
+		msg, ok := c.Get(response.ProtoKey)
+		if !ok || problem || status >= http.StatusMultipleChoices {
+			return nil, "", false
+		}
+		// the generated message is neither trimmed to ?fields= nor scrubbed like the document
+		if c.Query("fields") != "" || hasSensitiveFields(msg.(proto.Message).ProtoReflect()) {
+			return nil, "", false
+		}
+		out, err := proto.Marshal(msg.(proto.Message))
+		return out, codec.Protobuf, err == nil
+	}
+	return nil, "", false
+}
+
+// representationSuffix tells the representations the Accept header and ?fields= select apart,
+// it is empty for the whole JSON document
+func representationSuffix(accepted []string, fields string) string {
+	if fields == "" && (len(accepted) == 0 || accepted[0] == codec.JSON) {
+		return ""
+	}
+	sum := sha1.Sum([]byte(strings.Join(accepted, ",") + "|" + fields))
+	return "r" + hex.EncodeToString(sum[:4])
+}
+
+func isRepresentationSuffix(s string) bool {
+	if len(s) != 9 || s[0] != 'r' {
+		return false
+	}
+	_, err := hex.DecodeString(s[1:])
+	return err == nil
+}
+
+func notAcceptable(c *gin.Context) {
+	response.Error(c, http.StatusNotAcceptable, "Accept must allow one of "+strings.Join(responseTypes, ", "))
+}
+
+// prefersProtobuf reports whether the response is going to be the generated message rather than a document
+func prefersProtobuf(c *gin.Context) bool {
+	accepted := c.GetStringSlice(mediaTypesKey)
+	return len(accepted) > 0 && accepted[0] == codec.Protobuf
+}
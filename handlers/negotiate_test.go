@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"blogpost/codec"
+	"blogpost/genprotos/author"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateProtobuf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Negotiate(), SanitizeResponse())
+	router.GET("/author", SparseFields(models.Author{}), func(c *gin.Context) {
+		found := &author.Author{Id: "1", Fullname: "Jane Doe"}
+		response.SetProto(c, found)
+		response.OK(c, http.StatusOK, "OK", models.NewAuthor(found))
+	})
+	router.GET("/user", func(c *gin.Context) {
+		response.SetProto(c, testUser)
+		response.OK(c, http.StatusOK, "OK", models.NewUser(testUser))
+	})
+
+	tests := []struct {
+		name        string
+		target      string
+		status      int
+		contentType string
+	}{
+		{name: "message", target: "/author", status: http.StatusOK, contentType: codec.Protobuf},
+		{name: "fields", target: "/author?fields=id", status: http.StatusNotAcceptable},
+		{name: "sensitive message", target: "/user", status: http.StatusNotAcceptable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			req.Header.Set("Accept", codec.Protobuf)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.contentType != "" && w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", w.Header().Get("Content-Type"), tt.contentType)
+			}
+			if w.Code == http.StatusNotAcceptable {
+				assertNoSensitiveKeys(t, w.Body.Bytes())
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"blogpost/response"
+	"blogpost/validation"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+)
+
+// Patch document media types
+const (
+	MergePatchContentType = "application/merge-patch+json"
+	JSONPatchContentType  = "application/json-patch+json"
+)
+
+// maxPatchSize bounds how much of a patch body is read
+const maxPatchSize = 1 << 20
+
+// applyPatch applies the request body as a JSON Merge Patch (RFC 7396) or JSON Patch (RFC 6902)
+// to current and decodes the validated result into out, on failure it has already written the problem
+func applyPatch(c *gin.Context, current interface{}, out interface{}) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType != MergePatchContentType && mediaType != JSONPatchContentType {
+		response.Error(c, http.StatusUnsupportedMediaType, "Content-Type must be "+MergePatchContentType+" or "+JSONPatchContentType)
+		return false
+	}
+
+	patch, err := io.ReadAll(io.LimitReader(c.Request.Body, maxPatchSize))
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, err.Error())
+		return false
+	}
+
+	var patched []byte
+	if mediaType == MergePatchContentType {
+		patched, err = jsonpatch.MergePatch(doc, patch)
+	} else {
+		var ops jsonpatch.Patch
+		ops, err = jsonpatch.DecodePatch(patch)
+		if err == nil {
+			patched, err = ops.Apply(doc)
+		}
+	}
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "patch cannot be applied: "+err.Error())
+		return false
+	}
+
+	if err := json.Unmarshal(patched, out); err != nil {
+		return checkBinding(c, err)
+	}
+	return checkBinding(c, validation.Struct(out))
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"blogpost/markdown"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseRenderFormat reads ?format=, html renders article bodies and raw, the default, returns them as stored
+func parseRenderFormat(c *gin.Context) (html bool, err error) {
+	switch c.DefaultQuery("format", "raw") {
+	case "raw":
+		return false, nil
+	case "html":
+		return true, nil
+	}
+	return false, errors.New("format error")
+}
+
+// renderArticles fills in the rendered body of every article with ?format=html,
+// on failure it has already written the problem
+func (h Handler) renderArticles(c *gin.Context, list []models.Article) bool {
+	html, err := parseRenderFormat(c)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	if !html {
+		return true
+	}
+
+	for i := range list {
+		if list[i].Rendered, err = h.render(list[i].Body); err != nil {
+			response.Error(c, http.StatusInternalServerError, err.Error())
+			return false
+		}
+	}
+	return true
+}
+
+func (h Handler) render(body string) (*models.Rendered, error) {
+	r, err := markdown.Render(body, h.Conf.ExcerptLength, h.Conf.WordsPerMinute)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Rendered{
+		HTML:        r.HTML,
+		Excerpt:     r.Excerpt,
+		WordCount:   r.WordCount,
+		ReadingTime: r.ReadingTime,
+	}, nil
+}
+
+// variantETag keeps the entity tags of a resource and its rendered representation apart
+func variantETag(etag, variant string) string {
+	return strings.TrimSuffix(etag, `"`) + "-" + variant + `"`
+}
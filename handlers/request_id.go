@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader ...
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID keeps the caller's X-Request-ID or generates one, and echoes it back
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = uuid.New().String()
+		}
+
+		c.Set(response.RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// sensitiveKeys are removed from every JSON payload, compared case-insensitively
+var sensitiveKeys = map[string]struct{}{
+	"password":      {},
+	"password_hash": {},
+	"passwordhash":  {},
+	"old_password":  {},
+	"new_password":  {},
+}
+
+// bufferedWriter holds a JSON response body back so a middleware can rewrite it after the handler has run,
+// any other body such as a streamed export is passed through as it is written
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body        *bytes.Buffer
+	decided     bool
+	passthrough bool
+}
+
+func newBufferedWriter(w gin.ResponseWriter) *bufferedWriter {
+	return &bufferedWriter{ResponseWriter: w, body: &bytes.Buffer{}}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	if w.streaming() {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.streaming() {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+// streaming is decided by the Content-Type at the first write
+func (w *bufferedWriter) streaming() bool {
+	if !w.decided {
+		w.decided = true
+		w.passthrough = !isJSONDocument(w.Header().Get("Content-Type"))
+	}
+	return w.passthrough
+}
+
+// isJSONDocument reports whether contentType is application/json or a +json type, JSON Lines is not one document
+func isJSONDocument(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// SanitizeResponse is a safety net that scrubs sensitive keys from JSON responses,
+// handlers are still expected to map users through models.NewUser
+func SanitizeResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := newBufferedWriter(c.Writer)
+		c.Writer = w
+
+		c.Next()
+
+		c.Writer = w.ResponseWriter
+		body := w.body.Bytes()
+		if len(body) == 0 {
+			return
+		}
+		body = scrubJSON(body)
+		w.ResponseWriter.Write(body)
+	}
+}
+
+// scrubJSON returns the payload without sensitive keys, or unchanged if it is not valid JSON
+func scrubJSON(body []byte) []byte {
+	var payload interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return body
+	}
+
+	if !scrubValue(payload) {
+		return body
+	}
+
+	scrubbed, err := json.Marshal(payload)
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+// scrubValue deletes sensitive keys in place and reports whether anything was removed
+func scrubValue(v interface{}) bool {
+	removed := false
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if _, ok := sensitiveKeys[strings.ToLower(key)]; ok {
+				delete(value, key)
+				removed = true
+				continue
+			}
+			if scrubValue(child) {
+				removed = true
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if scrubValue(child) {
+				removed = true
+			}
+		}
+	}
+	return removed
+}
+
+// hasSensitiveFields reports whether a sensitive key is set anywhere in msg
+func hasSensitiveFields(msg protoreflect.Message) bool {
+	found := false
+	msg.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if _, ok := sensitiveKeys[strings.ToLower(string(fd.Name()))]; ok {
+			found = true
+		} else if fd.Message() != nil && !fd.IsMap() {
+			if fd.IsList() {
+				for i := 0; i < v.List().Len() && !found; i++ {
+					found = hasSensitiveFields(v.List().Get(i).Message())
+				}
+			} else {
+				found = hasSensitiveFields(v.Message())
+			}
+		}
+		return !found
+	})
+	return found
+}
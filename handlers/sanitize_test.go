@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestScrubJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "top level",
+			in:   `{"id":"1","password":"secret"}`,
+			want: `{"id":"1"}`,
+		},
+		{
+			name: "case insensitive",
+			in:   `{"id":"1","Password":"secret","PasswordHash":"hash","OLD_PASSWORD":"old"}`,
+			want: `{"id":"1"}`,
+		},
+		{
+			name: "nested objects",
+			in:   `{"data":{"user":{"id":"1","password_hash":"hash","profile":{"new_password":"new"}}}}`,
+			want: `{"data":{"user":{"id":"1","profile":{}}}}`,
+		},
+		{
+			name: "arrays",
+			in:   `{"data":[{"id":"1","password":"a"},{"id":"2","users":[{"id":"3","password":"b"}]}]}`,
+			want: `{"data":[{"id":"1"},{"id":"2","users":[{"id":"3"}]}]}`,
+		},
+		{
+			name: "top level array",
+			in:   `[{"password":"a"},[{"password":"b","id":"2"}]]`,
+			want: `[{},[{"id":"2"}]]`,
+		},
+		{
+			name: "sensitive key holding an object",
+			in:   `{"password":{"value":"a"},"id":"1"}`,
+			want: `{"id":"1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scrubJSON([]byte(tt.in))
+			if !jsonEqual(t, got, []byte(tt.want)) {
+				t.Errorf("scrubJSON(%s) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrubJSONKeepsPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+	}{
+		{name: "nothing sensitive", in: `{"data":{"id":"1","password_hint":"x"},"message":"OK"}`},
+		{name: "large number", in: `{"id":12345678901234567890}`},
+		{name: "sensitive word as a value", in: `{"field":"password"}`},
+		{name: "not JSON", in: `password=secret`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrubJSON([]byte(tt.in)); string(got) != tt.in {
+				t.Errorf("scrubJSON(%s) = %s, want it unchanged", tt.in, got)
+			}
+		})
+	}
+}
+
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	ea, _ := json.Marshal(va)
+	eb, _ := json.Marshal(vb)
+	return string(ea) == string(eb)
+}
+
+func TestHasSensitiveFields(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  proto.Message
+		want bool
+	}{
+		{name: "user with password", msg: &authorization.User{Id: "1", Password: "secret"}, want: true},
+		{name: "user without password", msg: &authorization.User{Id: "1"}, want: false},
+		{name: "nested user", msg: &authorization.HasAccessResponse{User: &authorization.User{Password: "secret"}}, want: true},
+		{name: "user in a list", msg: &authorization.GetUserListResponse{Users: []*authorization.User{{Id: "1"}, {Password: "secret"}}}, want: true},
+		{name: "author", msg: &author.Author{Id: "1", Fullname: "Jane Doe"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasSensitiveFields(tt.msg.ProtoReflect()); got != tt.want {
+				t.Errorf("hasSensitiveFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
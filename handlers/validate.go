@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"blogpost/codec"
+	"blogpost/models"
+	"blogpost/response"
+	"blogpost/validation"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// maxBodySize bounds how much of a request body bindBody reads, whatever its Content-Type
+const maxBodySize = 1 << 20
+
+// bindBody decodes the body into obj by its Content-Type and validates it. JSON is the default, XML and
+// MessagePack are read as the JSON document they stand for and protobuf as the generated request message
+// of models implementing models.ProtoBody. On failure it has already written the problem
+func bindBody(c *gin.Context, obj interface{}) bool {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+	switch mediaType := codec.Canonical(c.ContentType()); mediaType {
+	case "", codec.JSON:
+		return checkBinding(c, c.ShouldBindWith(obj, validation.JSON))
+	case codec.XML, codec.MsgPack:
+		raw, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			readError(c, err)
+			return false
+		}
+		var document []byte
+		if mediaType == codec.XML {
+			document, err = codec.XMLToJSON(raw, reflect.TypeOf(obj))
+		} else {
+			document, err = codec.MsgPackToJSON(raw)
+		}
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(document))
+		return checkBinding(c, c.ShouldBindWith(obj, validation.JSON))
+	case codec.Protobuf:
+		body, ok := obj.(models.ProtoBody)
+		if !ok {
+			break
+		}
+		raw, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			readError(c, err)
+			return false
+		}
+		if err := body.UnmarshalProto(raw); err != nil {
+			response.Error(c, http.StatusBadRequest, err.Error())
+			return false
+		}
+		return checkBinding(c, validation.Struct(obj))
+	}
+
+	response.Error(c, http.StatusUnsupportedMediaType, "Content-Type must be "+strings.Join(bodyTypes(obj), ", "))
+	return false
+}
+
+func bodyTypes(obj interface{}) []string {
+	types := []string{codec.JSON, codec.XML, codec.MsgPack}
+	if _, ok := obj.(models.ProtoBody); ok {
+		types = append(types, codec.Protobuf)
+	}
+	return types
+}
+
+// checkBinding answers 422 for invalid fields and 400 for a body that is not JSON at all
+func checkBinding(c *gin.Context, err error) bool {
+	if err == nil {
+		return true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		response.ValidationError(c, "request body is invalid", validation.FieldErrors(validationErrs))
+		return false
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		response.ValidationError(c, "request body is invalid", []models.FieldError{{
+			Field:   typeErr.Field,
+			Message: "must be a " + typeErr.Type.String(),
+		}})
+		return false
+	}
+
+	readError(c, err)
+	return false
+}
+
+// readError answers 413 when the body was cut off by http.MaxBytesReader and 400 otherwise
+func readError(c *gin.Context, err error) {
+	if isBodyTooLarge(err) {
+		response.Error(c, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	response.Error(c, http.StatusBadRequest, err.Error())
+}
+
+// isBodyTooLarge reports whether err comes from reading past the limit of http.MaxBytesReader
+func isBodyTooLarge(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if err.Error() == "http: request body too large" {
+			return true
+		}
+	}
+	return false
+}
+
+// pathID returns the id path param when it is a valid UUID
+func pathID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if errs := validation.Var("id", id, "required,uuid"); len(errs) > 0 {
+		response.ValidationError(c, "path is invalid", errs)
+		return "", false
+	}
+	return id, true
+}
+
+// checkBodyID makes sure an id sent in a body or produced by a patch is the one in the path
+func checkBodyID(c *gin.Context, id, bodyID string) bool {
+	if id == bodyID {
+		return true
+	}
+	response.ValidationError(c, "request body is invalid", []models.FieldError{{
+		Field:   "id",
+		Message: "must match the id in the path",
+	}})
+	return false
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"blogpost/codec"
+	"blogpost/models"
+	"blogpost/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestBindBodyLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/author", func(c *gin.Context) {
+		var body models.CreateAuthorModel
+		if !bindBody(c, &body) {
+			return
+		}
+		response.OK(c, http.StatusCreated, "Created", nil)
+	})
+
+	long := strings.Repeat("a", maxBodySize)
+	packed, err := msgpack.Marshal(map[string]string{"fullname": long})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		status      int
+	}{
+		{name: "json", contentType: codec.JSON, body: []byte(`{"fullname":"John Doe"}`), status: http.StatusCreated},
+		{name: "json over the limit", contentType: codec.JSON, body: []byte(`{"fullname":"` + long + `"}`), status: http.StatusRequestEntityTooLarge},
+		{name: "xml", contentType: codec.XML, body: []byte(`<request><fullname>John Doe</fullname></request>`), status: http.StatusCreated},
+		{name: "xml over the limit", contentType: codec.XML, body: []byte(`<request><fullname>` + long + `</fullname></request>`), status: http.StatusRequestEntityTooLarge},
+		{name: "msgpack over the limit", contentType: codec.MsgPack, body: packed, status: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/author", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %.200s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
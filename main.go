@@ -1,11 +1,17 @@
 package main
 
 import (
+	"blogpost/cache"
 	"blogpost/clients"
 	"blogpost/config"
 	docs "blogpost/docs" // docs is generated by Swag CLI, you have to import it.
 	"blogpost/handlers"
+	"blogpost/metrics"
+	"blogpost/models"
+	"blogpost/response"
+	"blogpost/validation"
 	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -14,6 +20,8 @@ import (
 
 // @license.name Apache 2.0
 // @license.url  http://www.apache.org/licenses/LICENSE-2.0.html
+// @accept       json,application/xml,application/msgpack,application/x-protobuf
+// @produce      json,application/xml,application/msgpack,application/x-protobuf
 func main() {
 	conf := config.Load()
 
@@ -21,6 +29,10 @@ func main() {
 	docs.SwaggerInfo.Title = conf.App
 	docs.SwaggerInfo.Version = conf.AppVersion
 
+	if err := validation.Register(); err != nil {
+		panic(err)
+	}
+
 	router := gin.New()
 
 	if conf.Environment != "development" {
@@ -31,6 +43,11 @@ func main() {
 		router.Use(gin.Logger(), gin.Recovery()) // Later they will be replaced by custom Logger and Recovery
 	}
 
+	router.Use(handlers.RequestID())
+	router.NoRoute(func(c *gin.Context) {
+		response.Error(c, http.StatusNotFound, "route not found")
+	})
+
 	grpcClients, err := clients.NewGrpcClients(conf)
 
 	if err != nil {
@@ -39,28 +56,83 @@ func main() {
 
 	defer grpcClients.Close()
 
-	h := handlers.NewHandler(conf, grpcClients)
+	store, err := cache.NewStore(conf)
+	if err != nil {
+		panic(err)
+	}
+	if store != nil {
+		defer store.Close()
+	}
+
+	if conf.CursorSecret == "" {
+		log.Println("CURSOR_SECRET is empty, list cursors are signed with a random key and break on restart or on another instance")
+	}
+	h, err := handlers.NewHandler(conf, grpcClients, store)
+	if err != nil {
+		panic(err)
+	}
+	router.Use(h.Compress())
 
 	v1 := router.Group("/v1")
 	{
-		v1.Use(MyCORSMiddleware())
+		v1.Use(MyCORSMiddleware(), handlers.Negotiate(), handlers.SanitizeResponse())
 		v1.POST("/login", h.Login)
 
-		v1.POST("/article", h.AuthMiddleware("*"), h.CreateArticle)
-		v1.GET("/article/:id", h.AuthMiddleware("*"), h.GetArticleByID)
-		v1.GET("/article", h.AuthMiddleware("*"), h.GetArticleList)
-		v1.PUT("/article", h.AuthMiddleware("*"), h.UpdateArticle)
-		v1.DELETE("/article/:id", h.AuthMiddleware("*"), h.DeleteArticle)
-
-		v1.POST("/author", h.AuthMiddleware("*"), h.CreateAuthor)
-		v1.GET("/author/:id", h.AuthMiddleware("*"), h.GetAuthorByID)
-		v1.GET("/author", h.AuthMiddleware("*"), h.GetAuthorList)
-		v1.PUT("/author", h.AuthMiddleware("*"), h.UpdateAuthor)
-		v1.DELETE("/author/:id", h.AuthMiddleware("*"), h.DeleteAuthor)
+		// article lists hold models.PackedArticleModel items with ?expand=author
+		articleListFields := handlers.SparseFields(models.Article{}, models.PackedArticleModel{})
+
+		articleWrite := h.Invalidates(handlers.CacheArticles)
+		v1.POST("/article", h.AuthMiddleware("*"), handlers.NoCoalescing(), articleWrite, h.CreateArticle)
+		v1.POST("/article/import", h.AuthMiddleware("*"), handlers.NoCoalescing(), handlers.DecompressBody(), h.ImportArticles)
+		v1.GET("/article/export", h.AuthMiddleware("*"), h.ExportArticles)
+		v1.GET("/article/:id", h.AuthMiddleware("*"), h.Cached(conf.CacheArticleTTL, handlers.CacheArticles, handlers.CacheAuthors), handlers.SparseFields(models.PackedArticleModel{}), h.GetArticleByID)
+		v1.GET("/article", h.AuthMiddleware("*"), h.Cached(conf.CacheArticleListTTL, handlers.CacheArticles, handlers.CacheAuthors), articleListFields, h.GetArticleList)
+		v1.PUT("/article", h.Deprecated("/v1/article/{id}"), h.AuthMiddleware("*"), handlers.NoCoalescing(), articleWrite, h.UpdateArticle)
+		v1.PUT("/article/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), articleWrite, h.UpdateArticleByID)
+		v1.PATCH("/article/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), articleWrite, h.PatchArticle)
+		v1.DELETE("/article/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), articleWrite, h.DeleteArticle)
+
+		authorWrite := h.Invalidates(handlers.CacheAuthors)
+		v1.POST("/author", h.AuthMiddleware("*"), handlers.NoCoalescing(), authorWrite, h.CreateAuthor)
+		v1.GET("/author/export", h.AuthMiddleware("*"), h.ExportAuthors)
+		v1.GET("/author/:id", h.AuthMiddleware("*"), h.Cached(conf.CacheAuthorTTL, handlers.CacheAuthors, handlers.CacheArticles), handlers.SparseFields(models.Author{}, models.AuthorWithArticles{}), h.GetAuthorByID)
+		v1.GET("/author/:id/articles", h.AuthMiddleware("*"), articleListFields, h.GetAuthorArticles)
+		v1.GET("/author", h.AuthMiddleware("*"), h.Cached(conf.CacheAuthorListTTL, handlers.CacheAuthors), handlers.SparseFields(models.Author{}), h.GetAuthorList)
+		v1.PUT("/author", h.Deprecated("/v1/author/{id}"), h.AuthMiddleware("*"), handlers.NoCoalescing(), authorWrite, h.UpdateAuthor)
+		v1.PUT("/author/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), authorWrite, h.UpdateAuthorByID)
+		v1.PATCH("/author/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), authorWrite, h.PatchAuthor)
+		v1.DELETE("/author/:id", h.AuthMiddleware("*"), handlers.NoCoalescing(), authorWrite, h.DeleteAuthor)
+
+		v1.POST("/batch", h.AuthMiddleware("*"), h.Batch)
+		v1.GET("/events", h.AuthMiddleware("*"), h.Events)
+
+		v1.GET("/me", h.AuthMiddleware("*"), handlers.SparseFields(models.User{}), h.GetMe)
+		v1.PUT("/me/password", h.AuthMiddleware("*"), h.ChangeMyPassword)
+		v1.GET("/me/articles", h.AuthMiddleware("*"), articleListFields, h.GetMyArticles)
+	}
+
+	router.POST("/graphql", MyCORSMiddleware(), handlers.SanitizeResponse(), h.AuthMiddleware("*"), h.GraphQL())
+	if conf.Environment == "development" {
+		router.GET("/graphql", handlers.GraphiQL())
 	}
 
+	// feeds link to the gateway, so they are only served once PUBLIC_URL says where it is
+	if conf.PublicURL != "" {
+		feeds := router.Group("/feeds", MyCORSMiddleware(), h.CachedByPath(conf.FeedTTL, handlers.CacheArticles, handlers.CacheAuthors))
+		{
+			feeds.GET("/articles.rss", h.ArticlesRSS)
+			feeds.GET("/articles.atom", h.ArticlesAtom)
+			// the id param carries the .atom extension
+			feeds.GET("/author/:id", h.AuthorAtom)
+		}
+	}
+
+	// expvar also publishes the command line and memory stats, so only admins may read it
+	router.GET("/debug/vars", h.AuthMiddleware("admin"), metrics.Handler())
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	h.SetEngine(router)
+
 	router.Run(conf.HTTPPort) // listen and serve on 0.0.0.0:8080 (for windows "localhost:8080")
 }
 
@@ -72,7 +144,8 @@ func MyCORSMiddleware() gin.HandlerFunc {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Credentials", "true")
 		c.Header("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
+		c.Header("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID, Content-Encoding, If-Match, If-None-Match, If-Modified-Since, Last-Event-ID")
+		c.Header("Access-Control-Expose-Headers", "X-Request-ID, ETag, Age, X-Cache, Warning, Link, Last-Modified, X-Export-Error, X-Export-Count")
 		c.Header("Access-Control-Max-Age", "3600")
 
 		if c.Request.Method == "OPTIONS" {
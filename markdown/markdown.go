@@ -0,0 +1,83 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Rendered is an article body rendered for display
+type Rendered struct {
+	HTML        string
+	Excerpt     string
+	WordCount   int
+	ReadingTime int // minutes
+}
+
+var (
+	// raw HTML in a body is kept by goldmark and left to the allow-list
+	converter = goldmark.New(goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()))
+	// allowList keeps formatting, links, images and tables, links get rel="nofollow"
+	allowList = bluemonday.UGCPolicy()
+	// textOnly drops every tag, what is left is the escaped text
+	textOnly = bluemonday.StrictPolicy()
+)
+
+// Render converts body from CommonMark to sanitized HTML, the excerpt is at most excerptLength characters
+// of its text cut at a word boundary and the reading time assumes wordsPerMinute
+func Render(body string, excerptLength, wordsPerMinute int) (Rendered, error) {
+	var out bytes.Buffer
+	if err := converter.Convert([]byte(body), &out); err != nil {
+		return Rendered{}, err
+	}
+	safe := allowList.SanitizeBytes(out.Bytes())
+
+	words := strings.Fields(html.UnescapeString(textOnly.Sanitize(string(safe))))
+	return Rendered{
+		HTML:        string(safe),
+		Excerpt:     excerpt(words, excerptLength),
+		WordCount:   len(words),
+		ReadingTime: readingTime(len(words), wordsPerMinute),
+	}, nil
+}
+
+// excerpt joins words up to length characters, marking a cut with an ellipsis
+func excerpt(words []string, length int) string {
+	var b strings.Builder
+	n := 0
+	for _, word := range words {
+		size := utf8.RuneCountInString(word)
+		if n > 0 {
+			size++
+		}
+		if length > 0 && n+size > length {
+			if n == 0 {
+				// a single word longer than the excerpt is cut inside
+				return string([]rune(word)[:length]) + "…"
+			}
+			return b.String() + "…"
+		}
+		if n > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+		n += size
+	}
+	return b.String()
+}
+
+// readingTime rounds up to whole minutes, any text takes at least one
+func readingTime(words, wordsPerMinute int) int {
+	if words == 0 {
+		return 0
+	}
+	if wordsPerMinute <= 0 {
+		wordsPerMinute = 200
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -0,0 +1,79 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderRemovesUnsafeHTML(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		forbidden []string
+		kept      string
+	}{
+		{
+			name:      "script block",
+			body:      "Hello\n\n<script>alert('x')</script>\n\nWorld",
+			forbidden: []string{"<script", "alert("},
+			kept:      "World",
+		},
+		{
+			name:      "inline script",
+			body:      "Hello <script>alert('x')</script> world",
+			forbidden: []string{"<script", "alert("},
+			kept:      "world",
+		},
+		{
+			name:      "javascript markdown link",
+			body:      "[click](javascript:alert('x'))",
+			forbidden: []string{"javascript:"},
+			kept:      "click",
+		},
+		{
+			name:      "javascript html link",
+			body:      `<a href="JavaScript:alert('x')">click</a>`,
+			forbidden: []string{"javascript:", "JavaScript:"},
+			kept:      "click",
+		},
+		{
+			name:      "onerror attribute",
+			body:      `<img src="x.png" onerror="alert('x')">`,
+			forbidden: []string{"onerror", "alert("},
+			kept:      `src="x.png"`,
+		},
+		{
+			name:      "iframe",
+			body:      "Before\n\n<iframe src=\"https://evil.example\"></iframe>\n\nAfter",
+			forbidden: []string{"<iframe", "evil.example"},
+			kept:      "After",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.body, 200, 200)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, forbidden := range tt.forbidden {
+				if strings.Contains(got.HTML, forbidden) {
+					t.Errorf("Render(%q).HTML = %q, contains %q", tt.body, got.HTML, forbidden)
+				}
+			}
+			if !strings.Contains(got.HTML, tt.kept) {
+				t.Errorf("Render(%q).HTML = %q, want it to keep %q", tt.body, got.HTML, tt.kept)
+			}
+		})
+	}
+}
+
+func TestRenderKeepsSafeLinks(t *testing.T) {
+	got, err := Render("[docs](https://example.com/docs)", 200, 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got.HTML, `href="https://example.com/docs"`) || !strings.Contains(got.HTML, `rel="nofollow"`) {
+		t.Errorf("Render().HTML = %q, want the link with rel=nofollow", got.HTML)
+	}
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"expvar"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeprecatedRoutes counts requests per deprecated route
+var DeprecatedRoutes = expvar.NewMap("deprecated_route_requests")
+
+// CoalescedCalls counts gRPC calls per method answered by a call already in flight
+var CoalescedCalls = expvar.NewMap("grpc_coalesced_calls")
+
+// CompressedResponses counts responses per content encoding
+var CompressedResponses = expvar.NewMap("compressed_responses")
+
+// CompressedBytes counts response bytes per content encoding before (<encoding>_in) and after (<encoding>_out) compression
+var CompressedBytes = expvar.NewMap("compression_bytes")
+
+func init() {
+	expvar.Publish("compression_ratio", expvar.Func(compressionRatio))
+}
+
+// compressionRatio is the compressed size of the responses of each encoding as a fraction of their size
+func compressionRatio() interface{} {
+	ratios := make(map[string]float64)
+	CompressedBytes.Do(func(kv expvar.KeyValue) {
+		if !strings.HasSuffix(kv.Key, "_in") {
+			return
+		}
+		encoding := strings.TrimSuffix(kv.Key, "_in")
+		in, _ := kv.Value.(*expvar.Int)
+		out, _ := CompressedBytes.Get(encoding + "_out").(*expvar.Int)
+		if in != nil && out != nil && in.Value() > 0 {
+			ratios[encoding] = float64(out.Value()) / float64(in.Value())
+		}
+	})
+	return ratios
+}
+
+// Handler serves every published metric as JSON
+func Handler() gin.HandlerFunc {
+	return gin.WrapH(expvar.Handler())
+}
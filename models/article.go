@@ -4,8 +4,8 @@ import "time"
 
 // Content ...
 type Content struct {
-	Title string `json:"title" `
-	Body  string `json:"body" `
+	Title string `json:"title" binding:"required,notblank,min=2,max=255" mod:"trim" minLength:"2" maxLength:"255" example:"Lorem ipsum"`
+	Body  string `json:"body" binding:"required,notblank,max=20000" maxLength:"20000" example:"Lorem ipsum dolor sit amet"`
 }
 
 // Article ...
@@ -16,12 +16,21 @@ type Article struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	DeletedAt *time.Time `json:"-"`
+	*Rendered            // only with ?format=html
+}
+
+// Rendered is an article body rendered from Markdown
+type Rendered struct {
+	HTML        string `json:"html" example:"<p>Lorem ipsum dolor sit amet</p>"`
+	Excerpt     string `json:"excerpt" example:"Lorem ipsum dolor sit amet"`
+	WordCount   int    `json:"word_count" example:"5"`
+	ReadingTime int    `json:"reading_time" example:"1"` // minutes
 }
 
 // CreateArticleModel ...
 type CreateArticleModel struct {
 	Content         // Promoted fields
-	AuthorID string `json:"author_id" binding:"required"`
+	AuthorID string `json:"author_id" binding:"required,uuid" mod:"trim" format:"uuid"`
 }
 
 // PackedArticleModel ...
@@ -32,17 +41,26 @@ type PackedArticleModel struct {
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	DeletedAt *time.Time `json:"-"`
+	*Rendered            // only with ?format=html
 }
 
+// UpdateArticleModel ...
 type UpdateArticleModel struct {
-	ID      string `json:"id"`
+	ID      string `json:"id" binding:"required,uuid" mod:"trim" format:"uuid"`
+	Content        // Promoted fields
+}
+
+// UpdateArticleByIDModel is the body of PUT /v1/article/{id}, the id may be repeated but must match the path
+type UpdateArticleByIDModel struct {
+	ID      string `json:"id,omitempty" binding:"omitempty,uuid" mod:"trim" format:"uuid"`
 	Content        // Promoted fields
 }
 
+// DeleteArticleModel ...
 type DeleteArticleModel struct {
 	ID        string     `json:"id"`
 	Content              // Promoted fields
-	Author    Author     `json:"author"`
+	AuthorID  string     `json:"author_id"`
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at"`
 	DeletedAt *time.Time `json:"deleted_at"`
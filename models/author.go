@@ -23,11 +23,17 @@ type AuthorWithArticles struct {
 
 // CreateAuthorModel ...
 type CreateAuthorModel struct {
-	Fullname string `json:"fullname" binding:"required" minLength:"2" maxLength:"255" example:"John Doe Steve"`
+	Fullname string `json:"fullname" binding:"required,notblank,min=2,max=255" mod:"trim" minLength:"2" maxLength:"255" example:"John Doe Steve"`
 }
 
 // UpdateAuthorModel ...
 type UpdateAuthorModel struct {
-	ID       string `json:"id"`
-	Fullname string `json:"fullname" binding:"required" minLength:"2" maxLength:"255" example:"John Doe Steve"`
+	ID       string `json:"id" binding:"required,uuid" mod:"trim" format:"uuid"`
+	Fullname string `json:"fullname" binding:"required,notblank,min=2,max=255" mod:"trim" minLength:"2" maxLength:"255" example:"John Doe Steve"`
+}
+
+// UpdateAuthorByIDModel is the body of PUT /v1/author/{id}, the id may be repeated but must match the path
+type UpdateAuthorByIDModel struct {
+	ID       string `json:"id,omitempty" binding:"omitempty,uuid" mod:"trim" format:"uuid"`
+	Fullname string `json:"fullname" binding:"required,notblank,min=2,max=255" mod:"trim" minLength:"2" maxLength:"255" example:"John Doe Steve"`
 }
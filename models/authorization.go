@@ -2,11 +2,17 @@ package models
 
 // LoginModel ...
 type LoginModel struct {
-	Username string `json:"username" binding:"required"`
-	Password string `json:"password" binding:"required"`
+	Username string `json:"username" binding:"required,notblank,max=255" mod:"trim" maxLength:"255"`
+	Password string `json:"password" binding:"required,max=128" maxLength:"128"`
 }
 
 // TokenResponse ...
 type TokenResponse struct {
 	Token string `json:"token"`
 }
+
+// ChangePasswordModel ...
+type ChangePasswordModel struct {
+	OldPassword string `json:"old_password" binding:"required,max=128" maxLength:"128"`
+	NewPassword string `json:"new_password" binding:"required,min=8,max=128,nefield=OldPassword" minLength:"8" maxLength:"128"`
+}
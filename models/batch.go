@@ -0,0 +1,38 @@
+package models
+
+import "encoding/json"
+
+// Compensation outcomes of a created resource in a failed atomic batch
+const (
+	BatchRolledBack = "rolled_back"
+	BatchSkipped    = "skipped" // the response named no id to delete
+	BatchFailed     = "failed"  // the compensating delete did not succeed
+)
+
+// BatchRequest is the body of POST /v1/batch
+type BatchRequest struct {
+	// Atomic runs the operations one by one and undoes the created resources with compensating deletes
+	// when any operation fails, only GET and POST operations to routes that can be undone are allowed then
+	Atomic     bool             `json:"atomic"`
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,dive"`
+}
+
+// BatchOperation is one sub-request of a batch
+type BatchOperation struct {
+	ID          string `json:"id,omitempty" example:"create-1"`
+	Method      string `json:"method" binding:"required,oneof=GET POST PUT PATCH DELETE" mod:"trim" example:"POST"`
+	Path        string `json:"path" binding:"required,startswith=/v1/" mod:"trim" example:"/v1/article"`
+	ContentType string `json:"content_type,omitempty" example:"application/json"`
+	// Body is sent as it is for a JSON content_type, any other content_type needs a string holding the body
+	Body json.RawMessage `json:"body,omitempty" swaggertype:"object"`
+}
+
+// BatchResult is the outcome of one operation, in the order of the request
+type BatchResult struct {
+	ID      string            `json:"id,omitempty" example:"create-1"`
+	Status  int               `json:"status" example:"201"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty" swaggertype:"object"`
+	// Compensation tells what became of a created resource once an atomic batch failed
+	Compensation string `json:"compensation,omitempty" enums:"rolled_back,skipped,failed"`
+}
@@ -4,9 +4,32 @@ package models
 type JSONResponse struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	Meta    *Pagination `json:"meta,omitempty"`
 }
 
-// JSONErrorResponse ...
-type JSONErrorResponse struct {
-	Error string `json:"error"`
+// Pagination ...
+type Pagination struct {
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Count  int    `json:"count"`
+	Total  *int   `json:"total,omitempty"`
+	Next   string `json:"next,omitempty" example:"/v1/article?cursor=eyJvIjoxMCwibCI6MTB9.Nq3e&limit=10"`
+	Prev   string `json:"prev,omitempty"`
+}
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type      string       `json:"type" example:"about:blank"`
+	Title     string       `json:"title" example:"Not Found"`
+	Status    int          `json:"status" example:"404"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty" example:"/v1/article/1"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError ...
+type FieldError struct {
+	Field   string `json:"field" example:"title"`
+	Message string `json:"message" example:"is required"`
 }
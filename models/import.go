@@ -0,0 +1,37 @@
+package models
+
+// Import record statuses
+const (
+	ImportCreated = "created"
+	ImportValid   = "valid" // dry run only
+	ImportInvalid = "invalid"
+	ImportFailed  = "failed"
+)
+
+// ImportResult is the outcome of one imported record
+type ImportResult struct {
+	File   string       `json:"file" example:"posts.csv"`
+	Row    int          `json:"row" example:"2"`
+	Status string       `json:"status" enums:"created,valid,invalid,failed" example:"created"`
+	ID     string       `json:"id,omitempty"`
+	Title  string       `json:"title,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+	Error  string       `json:"error,omitempty"`
+}
+
+// ImportSummary counts the records of an import by status
+type ImportSummary struct {
+	DryRun  bool `json:"dry_run"`
+	Total   int  `json:"total"`
+	Created int  `json:"created"`
+	Valid   int  `json:"valid"`
+	Invalid int  `json:"invalid"`
+	Failed  int  `json:"failed"`
+}
+
+// ImportReport is the answer of POST /v1/article/import
+type ImportReport struct {
+	Summary ImportSummary  `json:"summary"`
+	Results []ImportResult `json:"results"`
+	Error   string         `json:"error,omitempty"` // set when the upload could not be read to the end
+}
@@ -0,0 +1,273 @@
+package models
+
+import (
+	"time"
+
+	"blogpost/genprotos/article"
+	"blogpost/genprotos/author"
+	"blogpost/genprotos/authorization"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// parseTime parses an RFC3339 timestamp coming from a service, an invalid value becomes the zero time
+func parseTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// parseTimePtr is like parseTime but returns nil for an empty or invalid value
+func parseTimePtr(s string) *time.Time {
+	if s == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// NewArticle maps an article as the article service returns it from create and list calls
+func NewArticle(a *article.AddArticleRes) Article {
+	return Article{
+		ID: a.GetId(),
+		Content: Content{
+			Title: a.GetContent().GetTitle(),
+			Body:  a.GetContent().GetBody(),
+		},
+		AuthorID:  a.GetAuthorId(),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewArticleList ...
+func NewArticleList(articles []*article.AddArticleRes) []Article {
+	list := make([]Article, 0, len(articles))
+	for _, a := range articles {
+		list = append(list, NewArticle(a))
+	}
+	return list
+}
+
+// NewPackedArticle maps an article together with its author
+func NewPackedArticle(a *article.GetArticleByIdRes) PackedArticleModel {
+	return PackedArticleModel{
+		ID: a.GetId(),
+		Content: Content{
+			Title: a.GetContent().GetTitle(),
+			Body:  a.GetContent().GetBody(),
+		},
+		Author: Author{
+			ID:        a.GetAuthori().GetId(),
+			Fullname:  a.GetAuthori().GetFullname(),
+			CreatedAt: parseTime(a.GetAuthori().GetCreatedAt()),
+			UpdatedAt: parseTimePtr(a.GetAuthori().GetUpdatedAt()),
+			DeletedAt: parseTimePtr(a.GetAuthori().GetDeletedAt()),
+		},
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewUpdatedArticle ...
+func NewUpdatedArticle(a *article.UpdateArticleRes) PackedArticleModel {
+	return PackedArticleModel{
+		ID: a.GetId(),
+		Content: Content{
+			Title: a.GetContent().GetTitle(),
+			Body:  a.GetContent().GetBody(),
+		},
+		Author: Author{
+			ID:        a.GetAuthori().GetId(),
+			Fullname:  a.GetAuthori().GetFullname(),
+			CreatedAt: parseTime(a.GetAuthori().GetCreatedAt()),
+			UpdatedAt: parseTimePtr(a.GetAuthori().GetUpdatedAt()),
+			DeletedAt: parseTimePtr(a.GetAuthori().GetDeletedAt()),
+		},
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewDeletedArticle ...
+func NewDeletedArticle(a *article.DeleteArticleRes) DeleteArticleModel {
+	return DeleteArticleModel{
+		ID: a.GetId(),
+		Content: Content{
+			Title: a.GetContent().GetTitle(),
+			Body:  a.GetContent().GetBody(),
+		},
+		AuthorID:  a.GetAuthorId(),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewArticleFromAuthorArticle maps an article as the author service returns it
+func NewArticleFromAuthorArticle(a *author.Article) Article {
+	return Article{
+		ID: a.GetId(),
+		Content: Content{
+			Title: a.GetContent().GetTitle(),
+			Body:  a.GetContent().GetBody(),
+		},
+		AuthorID:  a.GetAuthorId(),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewArticleListFromAuthorArticles ...
+func NewArticleListFromAuthorArticles(articles []*author.Article) []Article {
+	list := make([]Article, 0, len(articles))
+	for _, a := range articles {
+		list = append(list, NewArticleFromAuthorArticle(a))
+	}
+	return list
+}
+
+// NewPackedArticleFromArticle embeds author into an article of a list
+func NewPackedArticleFromArticle(a Article, author Author) PackedArticleModel {
+	return PackedArticleModel{
+		ID:        a.ID,
+		Content:   a.Content,
+		Author:    author,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+		DeletedAt: a.DeletedAt,
+		Rendered:  a.Rendered,
+	}
+}
+
+// NewAuthor ...
+func NewAuthor(a *author.Author) Author {
+	return Author{
+		ID:        a.GetId(),
+		Fullname:  a.GetFullname(),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewAuthorList ...
+func NewAuthorList(authors []*author.Author) []Author {
+	list := make([]Author, 0, len(authors))
+	for _, a := range authors {
+		list = append(list, NewAuthor(a))
+	}
+	return list
+}
+
+// NewAuthorFromRes maps an author fetched by id, its articles are left out
+func NewAuthorFromRes(a *author.GetAuthorByIdRes) Author {
+	return Author{
+		ID:        a.GetId(),
+		Fullname:  a.GetFullname(),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewAuthorWithArticles ...
+func NewAuthorWithArticles(a *author.GetAuthorByIdRes, articles []*author.Article) AuthorWithArticles {
+	return AuthorWithArticles{
+		ID:        a.GetId(),
+		Fullname:  a.GetFullname(),
+		Articles:  NewArticleListFromAuthorArticles(articles),
+		CreatedAt: parseTime(a.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(a.GetUpdatedAt()),
+		DeletedAt: parseTimePtr(a.GetDeletedAt()),
+	}
+}
+
+// NewTokenResponse ...
+func NewTokenResponse(t *authorization.TokenResponse) TokenResponse {
+	return TokenResponse{
+		Token: t.GetToken(),
+	}
+}
+
+// ProtoBody is a request model that can also be sent as the generated request message of its gRPC call
+type ProtoBody interface {
+	UnmarshalProto(data []byte) error
+}
+
+// UnmarshalProto reads an article.AddArticleReq
+func (m *CreateArticleModel) UnmarshalProto(data []byte) error {
+	var req article.AddArticleReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.AuthorID = req.GetAuthorId()
+	m.Title = req.GetContent().GetTitle()
+	m.Body = req.GetContent().GetBody()
+	return nil
+}
+
+// UnmarshalProto reads an article.UpdateArticleReq
+func (m *UpdateArticleModel) UnmarshalProto(data []byte) error {
+	var req article.UpdateArticleReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.ID = req.GetId()
+	m.Title = req.GetContent().GetTitle()
+	m.Body = req.GetContent().GetBody()
+	return nil
+}
+
+// UnmarshalProto reads an article.UpdateArticleReq
+func (m *UpdateArticleByIDModel) UnmarshalProto(data []byte) error {
+	var req article.UpdateArticleReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.ID = req.GetId()
+	m.Title = req.GetContent().GetTitle()
+	m.Body = req.GetContent().GetBody()
+	return nil
+}
+
+// UnmarshalProto reads an author.CreateAuthorReq, its ID is left to the author service and ignored
+func (m *CreateAuthorModel) UnmarshalProto(data []byte) error {
+	var req author.CreateAuthorReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.Fullname = req.GetFullname()
+	return nil
+}
+
+// UnmarshalProto reads an author.UpdateAuthorReq
+func (m *UpdateAuthorModel) UnmarshalProto(data []byte) error {
+	var req author.UpdateAuthorReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.ID = req.GetId()
+	m.Fullname = req.GetFullname()
+	return nil
+}
+
+// UnmarshalProto reads an author.UpdateAuthorReq
+func (m *UpdateAuthorByIDModel) UnmarshalProto(data []byte) error {
+	var req author.UpdateAuthorReq
+	if err := proto.Unmarshal(data, &req); err != nil {
+		return err
+	}
+	m.ID = req.GetId()
+	m.Fullname = req.GetFullname()
+	return nil
+}
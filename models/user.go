@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"blogpost/genprotos/authorization"
+)
+
+// User is the public representation of an authorization user, it never carries the password
+type User struct {
+	ID        string     `json:"id"`
+	Username  string     `json:"username"`
+	UserType  string     `json:"user_type"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// NewUser maps an authorization user field by field, so new proto fields are not exposed by accident
+func NewUser(u *authorization.User) User {
+	if u == nil {
+		return User{}
+	}
+
+	return User{
+		ID:        u.GetId(),
+		Username:  u.GetUsername(),
+		UserType:  u.GetUserType(),
+		CreatedAt: parseTime(u.GetCreatedAt()),
+		UpdatedAt: parseTimePtr(u.GetUpdatedAt()),
+	}
+}
+
+// NewUserList ...
+func NewUserList(users []*authorization.User) []User {
+	list := make([]User, 0, len(users))
+	for _, u := range users {
+		list = append(list, NewUser(u))
+	}
+	return list
+}
@@ -0,0 +1,120 @@
+package response
+
+import (
+	"net/http"
+
+	"blogpost/models"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProblemContentType ...
+const ProblemContentType = "application/problem+json"
+
+// Problem types, about:blank means the HTTP status title says it all
+const (
+	TypeDefault    = "about:blank"
+	TypeValidation = "/problems/validation"
+)
+
+// RequestIDKey is the context key the request id middleware stores the id under
+const RequestIDKey = "request_id"
+
+// ProtoKey is the context key of the generated message a response was made from
+const ProtoKey = "response_proto"
+
+// SetProto offers msg to clients asking for protobuf, it is sent as is instead of the JSON envelope
+func SetProto(c *gin.Context, msg proto.Message) {
+	c.Set(ProtoKey, msg)
+}
+
+// OK writes data in the success envelope
+func OK(c *gin.Context, code int, message string, data interface{}) {
+	c.JSON(code, models.JSONResponse{
+		Message: message,
+		Data:    data,
+	})
+}
+
+// List writes a page of data in the success envelope together with its pagination metadata
+func List(c *gin.Context, message string, data interface{}, meta models.Pagination) {
+	c.JSON(http.StatusOK, models.JSONResponse{
+		Message: message,
+		Data:    data,
+		Meta:    &meta,
+	})
+}
+
+// Error aborts with a problem of the default type
+func Error(c *gin.Context, code int, detail string) {
+	AbortWithProblem(c, models.Problem{
+		Type:   TypeDefault,
+		Status: code,
+		Detail: detail,
+	})
+}
+
+// ValidationError aborts with 422 listing every invalid field
+func ValidationError(c *gin.Context, detail string, errs []models.FieldError) {
+	AbortWithProblem(c, models.Problem{
+		Type:   TypeValidation,
+		Title:  "Validation Failed",
+		Status: http.StatusUnprocessableEntity,
+		Detail: detail,
+		Errors: errs,
+	})
+}
+
+// GRPCError aborts with a problem whose status follows the gRPC status code of err
+func GRPCError(c *gin.Context, err error) {
+	st, _ := status.FromError(err)
+	Error(c, httpStatus(st.Code()), st.Message())
+}
+
+// AbortWithProblem fills in what the caller left empty and writes the problem
+func AbortWithProblem(c *gin.Context, p models.Problem) {
+	if p.Type == "" {
+		p.Type = TypeDefault
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	p.RequestID = c.GetString(RequestIDKey)
+
+	c.Header("Content-Type", ProblemContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+func httpStatus(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.FailedPrecondition:
+		return http.StatusPreconditionFailed
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
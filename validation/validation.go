@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"blogpost/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// JSON decodes a request body, trims fields tagged mod:"trim" and validates the result,
+// use it with gin's ShouldBindWith instead of binding.JSON
+var JSON = jsonBinding{}
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string {
+	return "json"
+}
+
+func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	if err := json.NewDecoder(req.Body).Decode(obj); err != nil {
+		return err
+	}
+	return Struct(obj)
+}
+
+// Struct trims and validates an already decoded model
+func Struct(obj interface{}) error {
+	Trim(obj)
+	return binding.Validator.ValidateStruct(obj)
+}
+
+// Register adds the custom validators to gin's binding engine and makes
+// validation errors report json field names
+func Register() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("unexpected validator engine")
+	}
+
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.SplitN(fld.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return v.RegisterValidation("notblank", notBlank)
+}
+
+// Var validates a single value such as a path param against a tag
+func Var(field string, value interface{}, tag string) []models.FieldError {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	err := v.Var(value, tag)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+
+	fieldErrs := FieldErrors(errs)
+	for i := range fieldErrs {
+		fieldErrs[i].Field = field
+	}
+	return fieldErrs
+}
+
+// FieldErrors converts validator errors into one entry per invalid field
+func FieldErrors(errs validator.ValidationErrors) []models.FieldError {
+	list := make([]models.FieldError, 0, len(errs))
+	for _, e := range errs {
+		list = append(list, models.FieldError{
+			Field:   e.Field(),
+			Message: message(e),
+		})
+	}
+	return list
+}
+
+// Trim removes surrounding whitespace from every string field tagged mod:"trim", embedded structs
+// and slices of structs included
+func Trim(obj interface{}) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		switch field.Kind() {
+		case reflect.Struct:
+			Trim(field.Addr().Interface())
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				if field.Index(j).Kind() == reflect.Struct {
+					Trim(field.Index(j).Addr().Interface())
+				}
+			}
+		case reflect.String:
+			if t.Field(i).Tag.Get("mod") == "trim" {
+				field.SetString(strings.TrimSpace(field.String()))
+			}
+		}
+	}
+}
+
+func notBlank(fl validator.FieldLevel) bool {
+	field := fl.Field()
+	if field.Kind() != reflect.String {
+		return true
+	}
+	return strings.TrimSpace(field.String()) != ""
+}
+
+func message(e validator.FieldError) string {
+	switch e.Tag() {
+	case "required":
+		return "is required"
+	case "notblank":
+		return "must not be blank"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "min":
+		return fmt.Sprintf("must be at least %s characters long", e.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s characters long", e.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", e.Param())
+	case "nefield":
+		return fmt.Sprintf("must differ from %s", e.Param())
+	}
+	return fmt.Sprintf("failed on the '%s' rule", e.Tag())
+}